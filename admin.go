@@ -0,0 +1,182 @@
+package chord
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// ErrAdminUnauthorized is returned when an admin request does not carry the admin token
+var ErrAdminUnauthorized = errors.New("admin token missing or invalid")
+
+// AuditEntry represents the record of one admin request, allowed or not
+type AuditEntry struct {
+	Time   time.Time
+	Node   string
+	Remote string
+	Action string
+	Target string `json:",omitempty"`
+
+	// Allowed is false when the request was rejected before the action ran
+	Allowed bool
+	Error   string `json:",omitempty"`
+}
+
+// Admin serves the operator actions on a Chord server: joining a ring, starting and stopping it.
+// It is kept apart from the peer protocol installed by Transporter, so that it can be bound to a separate listener
+// such as localhost or a unix socket. Requests must carry the admin token as "Authorization: Bearer <token>",
+// and every request is recorded in the audit log
+type Admin struct {
+	server *Server
+	token  string
+	audit  io.Writer
+
+	joinPath  string
+	startPath string
+	stopPath  string
+
+	sync.Mutex
+}
+
+// NewAdmin initializes the admin API of server. An empty token disables authentication,
+// which is only safe on a listener that only trusted operators can reach
+func NewAdmin(server *Server, token string) *Admin {
+	if token == "" {
+		log.Printf("[WARN]admin API of %s has no token, every request is allowed", server.config.Host)
+	}
+	return &Admin{
+		server:    server,
+		token:     token,
+		joinPath:  "/join",
+		startPath: "/start",
+		stopPath:  "/stop",
+	}
+}
+
+// SetAuditLog sets the writer the audit log is written to as one json AuditEntry per line,
+// the audit log goes to the standard logger if not set
+func (a *Admin) SetAuditLog(w io.Writer) {
+	a.Lock()
+	defer a.Unlock()
+	a.audit = w
+}
+
+// Install applies the admin routes to an http router
+func (a *Admin) Install(mux *mux.Router) {
+	mux.HandleFunc(a.joinPath, a.handle("join", a.joinHandler)).Methods("POST")
+	mux.HandleFunc(a.startPath, a.handle("start", a.startHandler)).Methods("POST")
+	mux.HandleFunc(a.stopPath, a.handle("stop", a.stopHandler)).Methods("POST")
+}
+
+// authorize checks the admin token of a request
+func (a *Admin) authorize(r *http.Request) error {
+	if a.token == "" {
+		return nil
+	}
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ErrAdminUnauthorized
+	}
+	if subtle.ConstantTimeCompare([]byte(strings.TrimPrefix(header, "Bearer ")), []byte(a.token)) != 1 {
+		return ErrAdminUnauthorized
+	}
+	return nil
+}
+
+// handle wraps an admin action with authentication and audit logging.
+// The action returns its target, the message to answer with, and its error
+func (a *Admin) handle(action string, fn func(r *http.Request) (string, string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entry := &AuditEntry{
+			Time:   time.Now(),
+			Node:   a.server.config.Host,
+			Remote: remoteAddr(r),
+			Action: action,
+		}
+
+		if err := a.authorize(r); err != nil {
+			entry.Error = err.Error()
+			a.record(entry)
+			http.Error(w, fmt.Sprintf("unauthorized: %s", err), http.StatusUnauthorized)
+			return
+		}
+
+		entry.Allowed = true
+		target, msg, err := fn(r)
+		entry.Target = target
+		if err != nil {
+			entry.Error = err.Error()
+			a.record(entry)
+			http.Error(w, msg, http.StatusBadRequest)
+			return
+		}
+		a.record(entry)
+		fmt.Fprint(w, msg)
+	}
+}
+
+// record writes an entry to the audit log
+func (a *Admin) record(entry *AuditEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		log.Printf("[ERROR]audit.%s", err)
+		return
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	if a.audit == nil {
+		log.Printf("[AUDIT]%s", data)
+		return
+	}
+	if _, err := a.audit.Write(append(data, '\n')); err != nil {
+		log.Printf("[ERROR]audit.%s", err)
+	}
+}
+
+// remoteAddr returns the address an admin request came from, a request over a unix socket has none
+func remoteAddr(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		return host
+	}
+	if r.RemoteAddr == "" {
+		return "local"
+	}
+	return r.RemoteAddr
+}
+
+// joinHandler handles the post request for this server to join an existing Chord ring
+// the url pattern is '/join?host='
+func (a *Admin) joinHandler(r *http.Request) (string, string, error) {
+	host := r.URL.Query().Get("host")
+	if err := a.server.Join(host); err != nil {
+		return host, fmt.Sprintf("failed to join %s.%s", host, err), err
+	}
+	return host, fmt.Sprintf("success to join %s", host), nil
+}
+
+// startHandler handles the incoming request to start this Chord server
+func (a *Admin) startHandler(r *http.Request) (string, string, error) {
+	if err := a.server.Start(); err != nil {
+		return "", fmt.Sprintf("error to start server %s", a.server.config.Host), err
+	}
+	return "", fmt.Sprintf("success to start server %s", a.server.config.Host), nil
+}
+
+// stopHandler handles incoming request to stop the Chord server
+func (a *Admin) stopHandler(r *http.Request) (string, string, error) {
+	if err := a.server.Stop(); err != nil {
+		return "", fmt.Sprintf("error to stop server %s", a.server.config.Host), err
+	}
+	return "", fmt.Sprintf("success to stop server %s", a.server.config.Host), nil
+}
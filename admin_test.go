@@ -0,0 +1,111 @@
+package chord
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func newTestAdmin(t *testing.T, token string) (*Admin, *mux.Router, *bytes.Buffer, func()) {
+	nodes := newTestNodes(t, 1, nil)
+	admin := NewAdmin(nodes[0].server, token)
+	audit := &bytes.Buffer{}
+	admin.SetAuditLog(audit)
+	router := mux.NewRouter()
+	admin.Install(router)
+	return admin, router, audit, func() { stopTestNodes(nodes) }
+}
+
+func decodeAudit(t *testing.T, audit *bytes.Buffer) []*AuditEntry {
+	entries := []*AuditEntry{}
+	for _, line := range strings.Split(strings.TrimSpace(audit.String()), "\n") {
+		entry := &AuditEntry{}
+		if err := json.Unmarshal([]byte(line), entry); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func TestAdminRequiresToken(t *testing.T) {
+	admin, router, audit, stop := newTestAdmin(t, "secret")
+	defer stop()
+
+	for _, header := range []string{"", "Bearer wrong", "secret"} {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest("POST", "/stop", nil)
+		if header != "" {
+			r.Header.Set("Authorization", header)
+		}
+		router.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("expected status %d with authorization %q, got %d", http.StatusUnauthorized, header, w.Code)
+		}
+	}
+	if !admin.server.Running() {
+		t.Errorf("expected server to keep running after unauthorized stop")
+	}
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/stop", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d with token, got %d", http.StatusOK, w.Code)
+	}
+	if admin.server.Running() {
+		t.Errorf("expected server to be stopped")
+	}
+
+	entries := decodeAudit(t, audit)
+	if len(entries) != 4 {
+		t.Fatalf("expected 4 audit entries, got %d", len(entries))
+	}
+	for _, entry := range entries[:3] {
+		if entry.Allowed || entry.Action != "stop" || entry.Error == "" {
+			t.Errorf("expected rejected stop in audit log, got %+v", entry)
+		}
+	}
+	if last := entries[3]; !last.Allowed || last.Error != "" || last.Node != admin.server.config.Host {
+		t.Errorf("expected allowed stop in audit log, got %+v", last)
+	}
+}
+
+func TestAdminAuditsFailedJoin(t *testing.T) {
+	_, router, audit, stop := newTestAdmin(t, "")
+	defer stop()
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/join?host=http://127.0.0.1:1", nil))
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("expected status %d joining unreachable host, got %d", http.StatusBadRequest, w.Code)
+	}
+
+	entries := decodeAudit(t, audit)
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 audit entry, got %d", len(entries))
+	}
+	if entry := entries[0]; !entry.Allowed || entry.Action != "join" || entry.Target != "http://127.0.0.1:1" || entry.Error == "" {
+		t.Errorf("expected failed join in audit log, got %+v", entry)
+	}
+}
+
+func TestPeerRouterHasNoAdminRoutes(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+
+	resp, err := http.Post(nodes[0].server.config.Host+"/stop", "chord.stop", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("expected peer router to not serve /stop, got status %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,198 @@
+package chord
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultAuthMaxSkew is the max difference between the timestamp of a signed request and the local clock
+const DefaultAuthMaxSkew = 30 * time.Second
+
+const (
+	authTimestampHeader = "X-Chord-Timestamp"
+	authNonceHeader     = "X-Chord-Nonce"
+	authSignatureHeader = "X-Chord-Signature"
+)
+
+var (
+	// ErrAuthMissing is returned when a request or response is not signed
+	ErrAuthMissing = errors.New("missing signature")
+
+	// ErrAuthInvalid is returned when a signature does not match any active key
+	ErrAuthInvalid = errors.New("invalid signature")
+
+	// ErrAuthExpired is returned when the timestamp of a request is too far from the local clock
+	ErrAuthExpired = errors.New("request timestamp out of range")
+
+	// ErrAuthReplayed is returned when the nonce of a request has already been seen
+	ErrAuthReplayed = errors.New("request replayed")
+)
+
+// authenticator signs and verifies the requests and responses exchanged between nodes with shared-secret HMAC.
+// The first key signs, and every key verifies, so that keys can be rotated without downtime
+type authenticator struct {
+	maxSkew time.Duration
+	nonces  map[string]time.Time
+	sync.Mutex
+}
+
+func newAuthenticator(maxSkew time.Duration) *authenticator {
+	return &authenticator{
+		maxSkew: durationOrDefault(maxSkew, DefaultAuthMaxSkew),
+		nonces:  make(map[string]time.Time),
+	}
+}
+
+// sign computes the hex encoded HMAC-SHA256 of the parts with key
+func sign(key string, parts ...string) string {
+	mac := hmac.New(sha256.New, []byte(key))
+	for _, part := range parts {
+		mac.Write([]byte(part))
+		mac.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks the signature of the parts against every active key
+func verify(keys []string, signature string, parts ...string) error {
+	if signature == "" {
+		return ErrAuthMissing
+	}
+	for _, key := range keys {
+		if hmac.Equal([]byte(sign(key, parts...)), []byte(signature)) {
+			return nil
+		}
+	}
+	return ErrAuthInvalid
+}
+
+// requestParts returns the parts of a request covered by its signature
+func requestParts(r *http.Request, timestamp string, nonce string, body []byte) []string {
+	return []string{r.Method, r.URL.RequestURI(), timestamp, nonce, string(body)}
+}
+
+// responseParts returns the parts of a response covered by its signature, the response is bound to the request's nonce
+func responseParts(nonce string, status int, body []byte) []string {
+	return []string{"response", nonce, strconv.Itoa(status), string(body)}
+}
+
+// signRequest signs an outgoing request with the first key, and returns the nonce the response must be bound to
+func signRequest(keys []string, r *http.Request, body []byte) (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("sign request failed: %s", err)
+	}
+	nonce := hex.EncodeToString(b)
+	timestamp := strconv.FormatInt(time.Now().UnixNano(), 10)
+
+	r.Header.Set(authTimestampHeader, timestamp)
+	r.Header.Set(authNonceHeader, nonce)
+	r.Header.Set(authSignatureHeader, sign(keys[0], requestParts(r, timestamp, nonce, body)...))
+	return nonce, nil
+}
+
+// verifyRequest checks the signature, timestamp and nonce of an incoming request
+func (a *authenticator) verifyRequest(keys []string, r *http.Request, body []byte) error {
+	timestamp := r.Header.Get(authTimestampHeader)
+	nonce := r.Header.Get(authNonceHeader)
+	if timestamp == "" || nonce == "" {
+		return ErrAuthMissing
+	}
+	if err := verify(keys, r.Header.Get(authSignatureHeader), requestParts(r, timestamp, nonce, body)...); err != nil {
+		return err
+	}
+
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrAuthInvalid
+	}
+	now := time.Now()
+	sent := time.Unix(0, ts)
+	if sent.Before(now.Add(-a.maxSkew)) || sent.After(now.Add(a.maxSkew)) {
+		return ErrAuthExpired
+	}
+
+	a.Lock()
+	defer a.Unlock()
+	for n, expires := range a.nonces {
+		if now.After(expires) {
+			delete(a.nonces, n)
+		}
+	}
+	if _, ok := a.nonces[nonce]; ok {
+		return ErrAuthReplayed
+	}
+	// a nonce older than the skew window is rejected by its timestamp, so it can be forgotten after that
+	a.nonces[nonce] = sent.Add(a.maxSkew)
+	return nil
+}
+
+// verifyResponse checks that an incoming response is signed and bound to the nonce of its request
+func verifyResponse(keys []string, nonce string, resp *http.Response, body []byte) error {
+	return verify(keys, resp.Header.Get(authSignatureHeader), responseParts(nonce, resp.StatusCode, body)...)
+}
+
+// signedResponseWriter buffers a response, so that it can be signed before it is sent
+type signedResponseWriter struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *signedResponseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *signedResponseWriter) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *signedResponseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// authenticate wraps a handler to reject unsigned or replayed requests, and to sign its responses,
+// when the server has active keys configured
+func (a *authenticator) authenticate(server *Server, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys := server.AuthKeys()
+		if len(keys) == 0 {
+			handler(w, r)
+			return
+		}
+
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		if err := a.verifyRequest(keys, r, body); err != nil {
+			http.Error(w, fmt.Sprintf("unauthorized: %s", err), http.StatusUnauthorized)
+			return
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		sw := &signedResponseWriter{header: w.Header()}
+		handler(sw, r)
+		if sw.status == 0 {
+			sw.status = http.StatusOK
+		}
+
+		nonce := r.Header.Get(authNonceHeader)
+		w.Header().Set(authSignatureHeader, sign(keys[0], responseParts(nonce, sw.status, sw.body.Bytes())...))
+		w.WriteHeader(sw.status)
+		w.Write(sw.body.Bytes())
+	}
+}
@@ -0,0 +1,99 @@
+package chord
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// newAuthTestServer initializes a server with active keys behind an in-process router
+func newAuthTestServer(keys []string) (*Server, *mux.Router) {
+	httpTransporter := NewTransporter()
+	config := DefaultConfig("localhost")
+	config.AuthKeys = keys
+	server := NewServer("", config, httpTransporter)
+	router := mux.NewRouter()
+	httpTransporter.Install(server, router)
+	return server, router
+}
+
+func TestAuthRejectsUnsignedRequest(t *testing.T) {
+	_, router := newAuthTestServer([]string{"secret"})
+
+	var data bytes.Buffer
+	NewNotifyRequest([]byte{1}, "http://attacker", "localhost").Encode(&data)
+	req := httptest.NewRequest("POST", "/notify", &data)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected unsigned request to be rejected, got %d", rr.Code)
+	}
+}
+
+func TestAuthSignedRequestAndReplay(t *testing.T) {
+	_, router := newAuthTestServer([]string{"new", "old"})
+
+	// a sender that has not switched to the new key yet is still accepted
+	req := httptest.NewRequest("GET", "/getSuccessor", nil)
+	nonce, err := signRequest([]string{"old"}, req, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected signed request to be accepted, got %d", rr.Code)
+	}
+
+	resp := rr.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	if err := verifyResponse([]string{"old", "new"}, nonce, resp, body); err != nil {
+		t.Errorf("expected signed response, %s", err)
+	}
+	if err := verifyResponse([]string{"other"}, nonce, resp, body); err != ErrAuthInvalid {
+		t.Errorf("expected response signature to be invalid for other keys, got %v", err)
+	}
+
+	// resending the same request is rejected
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Errorf("expected replayed request to be rejected, got %d", rr.Code)
+	}
+}
+
+func TestAuthExpiredTimestamp(t *testing.T) {
+	a := newAuthenticator(time.Second)
+	req := httptest.NewRequest("GET", "/getSuccessor", nil)
+	signRequest([]string{"secret"}, req, nil)
+
+	// re-sign with a timestamp in the past
+	past := "1"
+	req.Header.Set(authTimestampHeader, past)
+	req.Header.Set(authSignatureHeader, sign("secret", requestParts(req, past, req.Header.Get(authNonceHeader), nil)...))
+	if err := a.verifyRequest([]string{"secret"}, req, nil); err != ErrAuthExpired {
+		t.Errorf("expected expired request, got %v", err)
+	}
+}
+
+func TestAuthRing(t *testing.T) {
+	nodes := newTestNodes(t, 3, nil)
+	defer stopTestNodes(nodes)
+	for _, node := range nodes {
+		node.server.SetAuthKeys([]string{"secret"})
+	}
+
+	joinTestRing(t, nodes)
+
+	// a node with the wrong key can not reach the ring
+	nodes[0].server.SetAuthKeys([]string{"wrong"})
+	if _, err := nodes[0].server.transporter.SendGetSuccessorRequest(nodes[0].server, nodes[1].server.config.Host); err == nil {
+		t.Error("expected request signed with the wrong key to fail")
+	}
+}
@@ -0,0 +1,376 @@
+package chord
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"time"
+)
+
+// DefaultLoadBalanceEpsilon is the ratio of loads below which a node is light enough to move if not configured
+const DefaultLoadBalanceEpsilon = 0.25
+
+// ErrBalanceIdentity is returned when load balancing would move a node whose ID is fixed by its identity
+var ErrBalanceIdentity = errors.New("node ID is fixed by its identity and can not move")
+
+// ErrBalancePredecessor is returned when the predecessor of a node changed between measuring its load and moving it,
+// so the range it would hand over to its successor is not the one it measured
+var ErrBalancePredecessor = errors.New("predecessor changed since the load was measured")
+
+const (
+	// transferExport asks a node for the data of the keys in a range
+	transferExport = "export"
+
+	// transferImport hands a node the data of the keys in a range
+	transferImport = "import"
+
+	// transferDelete asks a node to drop the data of the keys in a range it no longer owns
+	transferDelete = "delete"
+)
+
+// DataStore is implemented by an application storing data under the keys a node owns,
+// so that load balancing can measure the load of a node in keys, and move data when a node moves.
+// Ranges are (Start, End] as returned by OwnedRange
+type DataStore interface {
+	// KeyCount returns the number of keys stored in r
+	KeyCount(r *KeyRange) int
+
+	// SplitKey returns the key in r that has half of the keys in r at or before it, or nil if r has less than two keys
+	SplitKey(r *KeyRange) []byte
+
+	// Export returns the data of the keys in r, in a format Import understands
+	Export(r *KeyRange) ([]byte, error)
+
+	// Import stores data exported by another node, importing the same data twice must be harmless
+	Import(data []byte) error
+
+	// Delete drops the data of the keys in r
+	Delete(r *KeyRange) error
+}
+
+// LoadResponse represents the load of a node and how its range would be split, IDs are hex encoded
+type LoadResponse struct {
+	Host            string
+	ID              string
+	PredecessorID   string
+	PredecessorHost string
+	Load            float64
+
+	// Split is the ID a node moving next to this node takes, to take half of its load
+	Split string
+}
+
+// TransferRequest represents a request to export, import or delete the data of the keys in (Start, End]
+type TransferRequest struct {
+	Op    string
+	Start []byte
+	End   []byte
+	Data  []byte `json:",omitempty"`
+
+	targetHost string
+}
+
+// TransferResponse represents the answer to a TransferRequest, with the exported data
+type TransferResponse struct {
+	Data []byte `json:",omitempty"`
+}
+
+// NewTransferRequest initializes a new request of op on the data of the keys in (start, end], sent to targetHost
+func NewTransferRequest(op string, start []byte, end []byte, data []byte, targetHost string) *TransferRequest {
+	return &TransferRequest{
+		Op:         op,
+		Start:      start,
+		End:        end,
+		Data:       data,
+		targetHost: targetHost,
+	}
+}
+
+// Encode encodes TransferRequest into data buffer as json
+func (req *TransferRequest) Encode(w io.Writer) (int, error) {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return -1, fmt.Errorf("encode TransferRequest failed: %s", err)
+	}
+	return w.Write(data)
+}
+
+// Decode decodes json data from buffer and stores it in TransferRequest
+func (req *TransferRequest) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode TransferRequest failed: %s", err)
+	}
+	if err = json.Unmarshal(data, req); err != nil {
+		return -1, fmt.Errorf("decode TransferRequest failed: %s", err)
+	}
+	return len(data), nil
+}
+
+// SetDataStore sets the store of the data under the keys this node owns. With a store set,
+// load balancing measures load in keys and moves data along with the nodes, otherwise it balances range sizes
+func (server *Server) SetDataStore(store DataStore) {
+	server.Lock()
+	defer server.Unlock()
+	server.dataStore = store
+}
+
+// DataStore returns the store set on this node, or nil
+func (server *Server) DataStore() DataStore {
+	server.RLock()
+	defer server.RUnlock()
+	return server.dataStore
+}
+
+// load returns the load of this node, the keys it stores if it has a DataStore, or the size of its range,
+// and the ID splitting that load in two halves. It returns an error if the range of this node is not known
+func (server *Server) load() (*LoadResponse, error) {
+	localNode := server.node
+	localID := localNode.GetID()
+	pred := localNode.Predecessor()
+	if pred == nil || pred.host == server.config.Host {
+		return nil, fmt.Errorf("range of %s not known", server.config.Host)
+	}
+
+	resp := &LoadResponse{
+		Host:            server.config.Host,
+		ID:              hex.EncodeToString(localID),
+		PredecessorID:   hex.EncodeToString(pred.ID),
+		PredecessorHost: pred.host,
+	}
+	r := &KeyRange{Start: pred.ID, End: localID, Known: true}
+	gap := ringGap(pred.ID, localID, server.config.HashBits)
+
+	var split []byte
+	if store := server.DataStore(); store != nil {
+		resp.Load = float64(store.KeyCount(r))
+		split = store.SplitKey(r)
+	} else {
+		resp.Load, _ = new(big.Float).SetInt(gap).Float64()
+		if gap.Cmp(big.NewInt(1)) > 0 {
+			ceil := new(big.Int).Lsh(big.NewInt(1), uint(server.config.HashBits))
+			mid := new(big.Int).Add(new(big.Int).SetBytes(pred.ID), new(big.Int).Rsh(gap, 1))
+			split = mid.Mod(mid, ceil).Bytes()
+		}
+	}
+	// the split must leave keys on both sides, so neither node ends up with an empty range
+	if split != nil && between(pred.ID, localID, split) {
+		resp.Split = hex.EncodeToString(split)
+	}
+	return resp, nil
+}
+
+// processTransferRequest exports, imports or deletes the data of the keys in the range of req
+func (server *Server) processTransferRequest(req *TransferRequest) (*TransferResponse, error) {
+	store := server.DataStore()
+	if store == nil {
+		return nil, fmt.Errorf("Chord transfer failed: no data store on %s", server.config.Host)
+	}
+	r := &KeyRange{Start: req.Start, End: req.End, Known: true}
+
+	switch req.Op {
+	case transferExport:
+		data, err := store.Export(r)
+		if err != nil {
+			return nil, fmt.Errorf("Chord transfer failed: %s", err)
+		}
+		return &TransferResponse{Data: data}, nil
+	case transferImport:
+		if err := store.Import(req.Data); err != nil {
+			return nil, fmt.Errorf("Chord transfer failed: %s", err)
+		}
+	case transferDelete:
+		// data is only dropped once its keys moved to another node
+		if server.Owns(req.End) {
+			return nil, fmt.Errorf("Chord transfer failed: %s still owns %s", server.config.Host, r)
+		}
+		if err := store.Delete(r); err != nil {
+			return nil, fmt.Errorf("Chord transfer failed: %s", err)
+		}
+	default:
+		return nil, fmt.Errorf("Chord transfer failed: unknown operation %q", req.Op)
+	}
+	return &TransferResponse{}, nil
+}
+
+// Rebalance runs one round of the load balancing protocol of Karger and Ruhl: this node compares its load
+// with a random peer, and if its load is below LoadBalanceEpsilon times the load of the peer, it leaves the ring,
+// handing its data to its successor, and re-joins at the ID splitting the load of the peer,
+// taking half of its data. It returns whether this node moved
+func (server *Server) Rebalance() (bool, error) {
+	server.balanceLock.Lock()
+	defer server.balanceLock.Unlock()
+
+	config := server.config
+	if config.VerifyIdentity || config.IdentityVerifier != nil || config.ID != "" || config.IdentityKeyPath != "" {
+		return false, fmt.Errorf("Chord rebalance failed: %s", ErrBalanceIdentity)
+	}
+	mine, err := server.load()
+	if err != nil {
+		return false, fmt.Errorf("Chord rebalance failed: %s", err)
+	}
+	peers := server.samplePeers(1)
+	if len(peers) == 0 {
+		return false, nil
+	}
+	heavy, err := server.transporter.SendLoadRequest(server, peers[0])
+	if err != nil {
+		return false, fmt.Errorf("Chord rebalance failed: %s", err)
+	}
+
+	epsilon := config.LoadBalanceEpsilon
+	if epsilon <= 0 {
+		epsilon = DefaultLoadBalanceEpsilon
+	}
+	if heavy.Host == config.Host || heavy.Split == "" || mine.Load > epsilon*heavy.Load {
+		return false, nil
+	}
+
+	split, _ := hex.DecodeString(heavy.Split)
+	heavyPredID, _ := hex.DecodeString(heavy.PredecessorID)
+	split = new(big.Int).SetBytes(split).Bytes()
+	if bytes.Equal(split, server.node.GetID()) {
+		return false, nil
+	}
+
+	log.Printf("[Balance]host %s with load %g moves to %x to split the load %g of %s", config.Host, mine.Load, split, heavy.Load, heavy.Host)
+	if err = server.moveTo(split, heavyPredID, heavy, mine); err != nil {
+		return false, fmt.Errorf("Chord rebalance failed: %s", err)
+	}
+	return true, nil
+}
+
+// moveTo moves this node to id in the range (heavyPredID, heavy] of the heavy node. Data is only dropped after
+// the node taking it over has a copy: this node copies the data of its new range from the heavy node, hands
+// its own data to its successor before leaving and again once it left, and copies the new range again once
+// it owns it, so that the second copies catch the writes in between. The move is aborted if the predecessor of this node
+// is no longer the one in mine, the load it measured
+func (server *Server) moveTo(id []byte, heavyPredID []byte, heavy *LoadResponse, mine *LoadResponse) error {
+	localNode := server.node
+	localID := localNode.GetID()
+	pred := localNode.Predecessor()
+	if pred == nil || pred.host != mine.PredecessorHost || hex.EncodeToString(pred.ID) != mine.PredecessorID {
+		return ErrBalancePredecessor
+	}
+	store := server.DataStore()
+	heavyID, _ := hex.DecodeString(heavy.ID)
+	newRange := &KeyRange{Start: heavyPredID, End: id, Known: true}
+	successorOfThis := heavy.PredecessorHost == server.config.Host
+
+	if store != nil {
+		if err := server.copyRange(newRange, heavy.Host, store); err != nil {
+			return err
+		}
+	}
+
+	if !successorOfThis {
+		// hand the range of this node over to its successor, which owns it once this node left
+		var oldRange *KeyRange
+		succ := localNode.Successor()
+		if store != nil {
+			oldRange = &KeyRange{Start: pred.ID, End: localID, Known: true}
+			if err := server.handOver(oldRange, succ.host, store); err != nil {
+				if deleteErr := store.Delete(newRange); deleteErr != nil {
+					log.Printf("[ERROR]balance.delete.%s", deleteErr)
+				}
+				return err
+			}
+		}
+		// the neighbours also notice this node is gone by stabilizing if they could not be told
+		if err := server.Leave(); err != nil {
+			log.Printf("[ERROR]balance.leave.%s", err)
+		}
+		if oldRange != nil {
+			// hand the range over again to catch the writes since the first hand-off, the data is kept if it fails
+			err := server.handOver(oldRange, succ.host, store)
+			if err == nil {
+				err = store.Delete(oldRange)
+			}
+			if err != nil {
+				log.Printf("[ERROR]balance.delete.%s", err)
+			}
+		}
+	}
+
+	server.moveID(id)
+	if !successorOfThis {
+		// this node is alone after leaving, the heavy node is its successor at its new ID
+		localNode.SetSuccessor(NewRemoteNode(heavyID, heavy.Host))
+		localNode.SetPredecessor(nil)
+		server.setJoined(true)
+	}
+	// the heavy node takes this node as its predecessor from the notify of stabilizing
+	if err := server.stabilize(); err != nil {
+		return err
+	}
+
+	if store != nil {
+		if err := server.copyRange(newRange, heavy.Host, store); err != nil {
+			return err
+		}
+		req := NewTransferRequest(transferDelete, newRange.Start, newRange.End, nil, heavy.Host)
+		if _, err := server.transporter.SendTransferRequest(server, req); err != nil {
+			log.Printf("[ERROR]balance.delete.%s.%s", heavy.Host, err)
+		}
+	}
+	server.resetSchedules()
+	return nil
+}
+
+// handOver exports the data of the keys in r to host
+func (server *Server) handOver(r *KeyRange, host string, store DataStore) error {
+	data, err := store.Export(r)
+	if err != nil {
+		return err
+	}
+	_, err = server.transporter.SendTransferRequest(server, NewTransferRequest(transferImport, r.Start, r.End, data, host))
+	return err
+}
+
+// copyRange imports the data of the keys in r from host
+func (server *Server) copyRange(r *KeyRange, host string, store DataStore) error {
+	resp, err := server.transporter.SendTransferRequest(server, NewTransferRequest(transferExport, r.Start, r.End, nil, host))
+	if err != nil {
+		return err
+	}
+	return store.Import(resp.Data)
+}
+
+// moveID gives this node a new ID, the finger table is rebuilt for the new ID by fixing fingers
+func (server *Server) moveID(id []byte) {
+	node := server.node
+	node.SetID(id)
+	node.Lock()
+	node.finger = make([]*FingerEntry, server.config.HashBits)
+	node.Unlock()
+	if server.lookupCache != nil {
+		server.lookupCache.Invalidate(NewRemoteNode(id, server.config.Host))
+	}
+}
+
+// startPeriodicalRebalance starts the periodical load balancing
+func (server *Server) startPeriodicalRebalance() {
+	stopChan := server.stopChan
+	ticker := time.NewTicker(server.config.LoadBalanceInterval)
+	defer ticker.Stop()
+
+	log.Printf("chord.PeriodicalRebalance.host: %s.interval: %s", server.config.Host, server.config.LoadBalanceInterval)
+
+	for {
+		select {
+		case <-stopChan:
+			log.Printf("chord.PeriodicalRebalance.stop.%s", server.config.Host)
+			return
+		case <-ticker.C:
+			if _, err := server.Rebalance(); err != nil {
+				log.Printf("[ERROR]%s.chord.PeriodicalRebalance.error.%s", server.config.Host, err)
+			}
+		}
+	}
+}
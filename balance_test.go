@@ -0,0 +1,324 @@
+package chord
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mapStore is a DataStore keeping one byte keys in a map, exported as json with hex encoded keys
+type mapStore struct {
+	sync.Mutex
+	data map[string][]byte
+
+	// exported is called once data was exported, if set
+	exported func()
+}
+
+func newMapStore() *mapStore {
+	return &mapStore{data: map[string][]byte{}}
+}
+
+// keys returns the keys in r, ordered by their distance from the start of r
+func (s *mapStore) keys(r *KeyRange) [][]byte {
+	s.Lock()
+	defer s.Unlock()
+	keys := [][]byte{}
+	for key := range s.data {
+		if r.Contains([]byte(key)) {
+			keys = append(keys, []byte(key))
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return between(r.Start, keys[j], keys[i])
+	})
+	return keys
+}
+
+func (s *mapStore) has(key []byte) bool {
+	s.Lock()
+	defer s.Unlock()
+	_, ok := s.data[string(key)]
+	return ok
+}
+
+func (s *mapStore) KeyCount(r *KeyRange) int {
+	return len(s.keys(r))
+}
+
+func (s *mapStore) SplitKey(r *KeyRange) []byte {
+	keys := s.keys(r)
+	if len(keys) < 2 {
+		return nil
+	}
+	return keys[len(keys)/2-1]
+}
+
+func (s *mapStore) Export(r *KeyRange) ([]byte, error) {
+	exported := map[string][]byte{}
+	for _, key := range s.keys(r) {
+		s.Lock()
+		exported[hex.EncodeToString(key)] = s.data[string(key)]
+		s.Unlock()
+	}
+	data, err := json.Marshal(exported)
+	if s.exported != nil {
+		s.exported()
+	}
+	return data, err
+}
+
+func (s *mapStore) put(key []byte, value []byte) {
+	s.Lock()
+	defer s.Unlock()
+	s.data[string(key)] = value
+}
+
+func (s *mapStore) Import(data []byte) error {
+	imported := map[string][]byte{}
+	if err := json.Unmarshal(data, &imported); err != nil {
+		return err
+	}
+	s.Lock()
+	defer s.Unlock()
+	for key, value := range imported {
+		id, err := hex.DecodeString(key)
+		if err != nil {
+			return err
+		}
+		s.data[string(id)] = value
+	}
+	return nil
+}
+
+func (s *mapStore) Delete(r *KeyRange) error {
+	for _, key := range s.keys(r) {
+		s.Lock()
+		delete(s.data, string(key))
+		s.Unlock()
+	}
+	return nil
+}
+
+// rangeSize returns the size of the range a node owns in a stable ring
+func rangeSize(node *testNode) int64 {
+	pred := node.server.node.Predecessor()
+	return ringGap(pred.ID, node.server.node.ID, node.server.config.HashBits).Int64()
+}
+
+// rebalanceUntilMoved runs rounds of load balancing on node until it moves, each round compares it with a random peer
+func rebalanceUntilMoved(t *testing.T, node *testNode, nodes []*testNode) {
+	for _, peer := range nodes {
+		node.server.AddPeers(peer.server.config.Host)
+	}
+	for round := 0; round < 50; round++ {
+		moved, err := node.server.Rebalance()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if moved {
+			return
+		}
+	}
+	t.Fatalf("expected %s to move", node.server.config.Host)
+}
+
+func TestRebalanceSplitsRange(t *testing.T) {
+	nodes := newTestRing(t, 4, nil)
+	defer stopTestNodes(nodes)
+
+	// the node with the smallest range is lighter than any peer, and moves with an epsilon of 1
+	sorted := sortTestNodes(nodes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return rangeSize(sorted[i]) < rangeSize(sorted[j])
+	})
+	light := sorted[0]
+	light.server.config.LoadBalanceEpsilon = 1
+	before := map[string]int64{}
+	for _, node := range nodes {
+		before[node.server.config.Host] = rangeSize(node)
+	}
+
+	rebalanceUntilMoved(t, light, nodes)
+	waitFor(t, 10*time.Second, "ring to stabilize after moving", func() bool {
+		return testRingStable(nodes)
+	})
+	// the moved node took half of the range of its new successor
+	heavy := light.server.node.Successor().host
+	for _, node := range nodes {
+		if node.server.config.Host != heavy {
+			continue
+		}
+		if size, half := rangeSize(node), before[heavy]-before[heavy]/2; size != half {
+			t.Errorf("expected %s to keep %d of its range of %d, got %d", heavy, half, before[heavy], size)
+		}
+	}
+}
+
+func TestRebalanceConcurrentReads(t *testing.T) {
+	nodes := newTestRing(t, 4, nil)
+	defer stopTestNodes(nodes)
+
+	sorted := sortTestNodes(nodes)
+	sort.Slice(sorted, func(i, j int) bool {
+		return rangeSize(sorted[i]) < rangeSize(sorted[j])
+	})
+	light := sorted[0]
+	light.server.config.LoadBalanceEpsilon = 1
+
+	// ownership queries and lookups keep reading the ID of the node while it moves
+	stop := make(chan bool)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for k := 0; ; k++ {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				key := []byte{byte(k)}
+				light.server.Owns(key)
+				light.server.ownership([][]byte{key})
+				light.server.FindSuccessor(NewFindSuccessorRequest(key, ""))
+			}
+		}()
+	}
+
+	rebalanceUntilMoved(t, light, nodes)
+	close(stop)
+	wg.Wait()
+}
+
+func TestRebalanceMovesData(t *testing.T) {
+	nodes := newTestRing(t, 4, nil)
+	defer stopTestNodes(nodes)
+
+	stores := map[*testNode]*mapStore{}
+	for _, node := range nodes {
+		stores[node] = newMapStore()
+		node.server.SetDataStore(stores[node])
+	}
+
+	// every key is stored on the node with the largest range, all other nodes are empty
+	sorted := sortTestNodes(nodes)
+	heavyIndex := 0
+	for i := range sorted {
+		if rangeSize(sorted[i]) > rangeSize(sorted[heavyIndex]) {
+			heavyIndex = i
+		}
+	}
+	heavy := sorted[heavyIndex]
+	keys := [][]byte{}
+	owned := heavy.server.OwnedRange()
+	for i := 1; i < 256; i++ {
+		key := []byte{byte(i)}
+		if owned.Contains(key) {
+			keys = append(keys, key)
+			stores[heavy].data[string(key)] = []byte{byte(i)}
+		}
+	}
+	if len(keys) < 4 {
+		t.Skipf("range of the heaviest node holds only %d keys", len(keys))
+	}
+
+	// the light node is not the predecessor of the heavy node, so it hands its range over and leaves first
+	light := sorted[(heavyIndex+2)%len(sorted)]
+
+	// a write reaches the light node after it handed its range over, but before it left
+	late := append([]byte{}, light.server.node.GetID()...)
+	var once sync.Once
+	stores[light].exported = func() {
+		once.Do(func() {
+			stores[light].put(late, []byte("late"))
+		})
+	}
+	rebalanceUntilMoved(t, light, nodes)
+	waitFor(t, 10*time.Second, "ring to stabilize after moving", func() bool {
+		return testRingStable(nodes)
+	})
+
+	if got := stores[light].KeyCount(light.server.OwnedRange()); got < len(keys)/2-1 || got > len(keys)/2+1 {
+		t.Errorf("expected the moved node to take about half of %d keys, got %d", len(keys), got)
+	}
+	for _, key := range append(keys, late) {
+		for _, node := range nodes {
+			owns := node.server.Owns(key)
+			if has := stores[node].has(key); owns != has {
+				t.Errorf("expected %s to store key %x: %v, stored: %v", node.server.config.Host, key, owns, has)
+			}
+		}
+	}
+}
+
+func TestRebalanceFixedIdentity(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	defer stopTestNodes(nodes)
+
+	nodes[0].server.config.ID = "01"
+	if _, err := nodes[0].server.Rebalance(); err == nil || !strings.Contains(err.Error(), ErrBalanceIdentity.Error()) {
+		t.Errorf("expected %q, got %v", ErrBalanceIdentity, err)
+	}
+}
+
+func TestMoveAbortsOnChangedPredecessor(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	defer stopTestNodes(nodes)
+
+	light, heavy := nodes[0].server, nodes[1].server
+	mine, err := light.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	heavyLoad, err := heavy.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	split, _ := hex.DecodeString(heavyLoad.Split)
+	heavyPredID, _ := hex.DecodeString(heavyLoad.PredecessorID)
+
+	// another node became the predecessor after the load was measured
+	mine.PredecessorHost = "http://127.0.0.1:1"
+	id := light.node.GetID()
+	if err = light.moveTo(split, heavyPredID, heavyLoad, mine); err != ErrBalancePredecessor {
+		t.Errorf("expected %q, got %v", ErrBalancePredecessor, err)
+	}
+	if !bytes.Equal(light.node.GetID(), id) || !testRingStable(nodes) {
+		t.Error("expected the node to stay in place")
+	}
+}
+
+func TestTransferDeleteOwnedRange(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	defer stopTestNodes(nodes)
+
+	server := nodes[0].server
+	store := newMapStore()
+	server.SetDataStore(store)
+	owned := server.OwnedRange()
+	store.data[string(owned.End)] = []byte("value")
+
+	req := NewTransferRequest(transferDelete, owned.Start, owned.End, nil, server.config.Host)
+	if _, err := server.processTransferRequest(req); err == nil {
+		t.Error("expected deleting the range a node still owns to fail")
+	}
+	if !store.has(owned.End) {
+		t.Error("expected the data of an owned range to be kept")
+	}
+
+	req = NewTransferRequest(transferExport, owned.Start, owned.End, nil, server.config.Host)
+	resp, err := server.processTransferRequest(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(resp.Data, []byte("dmFsdWU=")) {
+		t.Errorf("expected the exported data to hold the value, got %s", resp.Data)
+	}
+}
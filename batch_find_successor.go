@@ -0,0 +1,114 @@
+package chord
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/wang502/chord/protobuf"
+)
+
+// BatchFindSuccessorRequest represents a request sent to other server to find the successors of many keys at once
+type BatchFindSuccessorRequest struct {
+	IDs  []string
+	host string
+}
+
+// BatchFindSuccessorResponse represents a response mapping every requested key to its owner
+type BatchFindSuccessorResponse struct {
+	Owners map[string]*RemoteNode
+}
+
+// NewBatchFindSuccessorRequest initializes a new request to find the successors of ids
+func NewBatchFindSuccessorRequest(ids [][]byte, host string) *BatchFindSuccessorRequest {
+	req := &BatchFindSuccessorRequest{
+		host: host,
+	}
+	for _, id := range ids {
+		req.IDs = append(req.IDs, string(id))
+	}
+	return req
+}
+
+// NewBatchFindSuccessorResponse initializes a new response from a map of key to owner
+func NewBatchFindSuccessorResponse(owners map[string]*RemoteNode) *BatchFindSuccessorResponse {
+	return &BatchFindSuccessorResponse{
+		Owners: owners,
+	}
+}
+
+// Encode encodes the BatchFindSuccessorRequest into a buffer
+// returns the number of bytes written to the buffer, and error if occurred
+func (req *BatchFindSuccessorRequest) Encode(w io.Writer) (int, error) {
+	pb := &pb.BatchFindSuccessorRequest{
+		Host: req.host,
+	}
+	for _, id := range req.IDs {
+		pb.IDs = append(pb.IDs, []byte(id))
+	}
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		return -1, fmt.Errorf("encode BatchFindSuccessorRequest failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes the bytes read from buffer and store data into BatchFindSuccessorRequest entry
+func (req *BatchFindSuccessorRequest) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode BatchFindSuccessorRequest failed: %s", err)
+	}
+
+	pb := &pb.BatchFindSuccessorRequest{}
+	if err = proto.Unmarshal(data, pb); err != nil {
+		return -1, fmt.Errorf("decode BatchFindSuccessorRequest failed: %s", err)
+	}
+
+	req.IDs = nil
+	for _, id := range pb.IDs {
+		req.IDs = append(req.IDs, string(id))
+	}
+	req.host = pb.Host
+	return len(data), nil
+}
+
+// Encode encodes the BatchFindSuccessorResponse into a buffer
+// returns the number of bytes written to the buffer, and error if occurred
+func (resp *BatchFindSuccessorResponse) Encode(w io.Writer) (int, error) {
+	pbResp := &pb.BatchFindSuccessorResponse{}
+	for key, owner := range resp.Owners {
+		pbResp.Owners = append(pbResp.Owners, &pb.KeyOwner{
+			Key:  []byte(key),
+			ID:   owner.ID,
+			Host: owner.host,
+		})
+	}
+	data, err := proto.Marshal(pbResp)
+	if err != nil {
+		return -1, fmt.Errorf("encode BatchFindSuccessorResponse failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes the bytes read from buffer and store data into BatchFindSuccessorResponse entry
+func (resp *BatchFindSuccessorResponse) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode BatchFindSuccessorResponse failed: %s", err)
+	}
+
+	pb := &pb.BatchFindSuccessorResponse{}
+	if err = proto.Unmarshal(data, pb); err != nil {
+		return -1, fmt.Errorf("decode BatchFindSuccessorResponse failed: %s", err)
+	}
+
+	resp.Owners = make(map[string]*RemoteNode)
+	for _, owner := range pb.Owners {
+		resp.Owners[string(owner.Key)] = NewRemoteNode(owner.ID, owner.Host)
+	}
+	return len(data), nil
+}
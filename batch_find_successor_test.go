@@ -0,0 +1,63 @@
+package chord
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBatchFindSuccessorEncoding(t *testing.T) {
+	req := NewBatchFindSuccessorRequest([][]byte{{1}, {2}}, "host1")
+	var buf bytes.Buffer
+	if _, err := req.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decodedReq := &BatchFindSuccessorRequest{}
+	if _, err := decodedReq.Decode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if len(decodedReq.IDs) != 2 || decodedReq.host != "host1" {
+		t.Error("wrong decoded BatchFindSuccessorRequest")
+	}
+
+	resp := NewBatchFindSuccessorResponse(map[string]*RemoteNode{"\x01": NewRemoteNode([]byte{3}, "host3")})
+	buf.Reset()
+	if _, err := resp.Encode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	decodedResp := &BatchFindSuccessorResponse{}
+	if _, err := decodedResp.Decode(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if owner := decodedResp.Owners["\x01"]; owner == nil || owner.host != "host3" || !bytes.Equal(owner.ID, []byte{3}) {
+		t.Error("wrong decoded BatchFindSuccessorResponse")
+	}
+}
+
+func TestFindSuccessors(t *testing.T) {
+	nodes := newTestRing(t, 4, nil)
+	defer stopTestNodes(nodes)
+
+	keys := [][]byte{}
+	for i := 0; i < 256; i += 7 {
+		keys = append(keys, []byte{byte(i)})
+	}
+
+	sorted := sortTestNodes(nodes)
+	owners, err := nodes[0].server.FindSuccessors(keys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range keys {
+		expected := sorted[0].server
+		for _, node := range sorted {
+			if bytes.Compare(node.server.node.ID, key) >= 0 {
+				expected = node.server
+				break
+			}
+		}
+		owner := owners[string(key)]
+		if owner == nil || owner.Host() != expected.config.Host {
+			t.Errorf("wrong owner %v of key %x, expected %s", owner, key, expected.config.Host)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package chord
+
+import (
+	"bytes"
+	"crypto/rand"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/wang502/chord/protobuf"
+)
+
+// maxSeenBroadcasts is the number of broadcast IDs a node remembers to deliver every broadcast once
+const maxSeenBroadcasts = 1024
+
+// broadcastBudget is the time the origin of a broadcast gives the ring to acknowledge it.
+// It stays below the timeout of the Transporter requests, so that the origin's children answer in time
+const broadcastBudget = 900 * time.Millisecond
+
+// BroadcastReceiver is optionally implemented by an Application to receive the broadcasts of the ring.
+// The Key of a broadcast message is its broadcast ID
+type BroadcastReceiver interface {
+	Receive(msg *Message) error
+}
+
+// BroadcastResult represents the acknowledgments of a broadcast, aggregated on the way back to the origin
+type BroadcastResult struct {
+	// Reached is the number of nodes the broadcast reached, the origin included
+	Reached int
+
+	// Rounds is the number of hops from the origin to the furthest node reached
+	Rounds int
+
+	// Failed maps the hosts the broadcast could not be sent to, or that failed to receive it, to the error
+	Failed map[string]string
+}
+
+// BroadcastRequest represents a broadcast sent to a node, which covers the nodes in (node, Limit)
+type BroadcastRequest struct {
+	MessageID  []byte
+	Payload    []byte
+	Limit      []byte
+	source     string
+	targetHost string
+	round      int
+	// budget is the time the sender waits for the acknowledgment, every round gives its children less of it
+	budget time.Duration
+}
+
+// BroadcastResponse represents the aggregated acknowledgments of the nodes a broadcast request covered
+type BroadcastResponse struct {
+	reached     int
+	rounds      int
+	failedHosts []string
+	errors      []string
+}
+
+// NewBroadcastRequest initializes a new broadcast request sent to targetHost, covering the nodes up to limit
+func NewBroadcastRequest(messageID []byte, payload []byte, source string, targetHost string, limit []byte) *BroadcastRequest {
+	return &BroadcastRequest{
+		MessageID:  messageID,
+		Payload:    payload,
+		Limit:      limit,
+		source:     source,
+		targetHost: targetHost,
+		budget:     broadcastBudget,
+	}
+}
+
+// Encode encodes BroadcastRequest into data buffer
+func (req *BroadcastRequest) Encode(w io.Writer) (int, error) {
+	pbReq := &pb.BroadcastRequest{
+		MessageID:  req.MessageID,
+		Payload:    req.Payload,
+		Source:     req.source,
+		TargetHost: req.targetHost,
+		Limit:      req.Limit,
+		Round:      int32(req.round),
+		Budget:     int64(req.budget),
+	}
+	data, err := proto.Marshal(pbReq)
+	if err != nil {
+		return -1, fmt.Errorf("encode BroadcastRequest failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes data from buffer and stores it in BroadcastRequest
+func (req *BroadcastRequest) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode BroadcastRequest failed: %s", err)
+	}
+
+	pbReq := &pb.BroadcastRequest{}
+	if err = proto.Unmarshal(data, pbReq); err != nil {
+		return -1, fmt.Errorf("decode BroadcastRequest failed: %s", err)
+	}
+
+	req.MessageID = pbReq.MessageID
+	req.Payload = pbReq.Payload
+	req.source = pbReq.Source
+	req.targetHost = pbReq.TargetHost
+	req.Limit = pbReq.Limit
+	req.round = int(pbReq.Round)
+	req.budget = time.Duration(pbReq.Budget)
+	if req.budget <= 0 {
+		req.budget = broadcastBudget
+	}
+	return len(data), nil
+}
+
+// Encode encodes BroadcastResponse into data buffer
+func (resp *BroadcastResponse) Encode(w io.Writer) (int, error) {
+	pbResp := &pb.BroadcastResponse{
+		Reached:     int32(resp.reached),
+		Rounds:      int32(resp.rounds),
+		FailedHosts: resp.failedHosts,
+		Errors:      resp.errors,
+	}
+	data, err := proto.Marshal(pbResp)
+	if err != nil {
+		return -1, fmt.Errorf("encode BroadcastResponse failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes data from buffer and stores it in BroadcastResponse
+func (resp *BroadcastResponse) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode BroadcastResponse failed: %s", err)
+	}
+
+	pbResp := &pb.BroadcastResponse{}
+	if err = proto.Unmarshal(data, pbResp); err != nil {
+		return -1, fmt.Errorf("decode BroadcastResponse failed: %s", err)
+	}
+
+	resp.reached = int(pbResp.Reached)
+	resp.rounds = int(pbResp.Rounds)
+	resp.failedHosts = pbResp.FailedHosts
+	resp.errors = pbResp.Errors
+	return len(data), nil
+}
+
+// fail records that host failed with err
+func (resp *BroadcastResponse) fail(host string, err error) {
+	resp.failedHosts = append(resp.failedHosts, host)
+	resp.errors = append(resp.errors, err.Error())
+}
+
+// Broadcast sends payload to every node of the ring, where it is received by the Application if it implements
+// BroadcastReceiver. Following El-Ansary's broadcast for Chord, every node splits the part of the ring it covers
+// between its distinct fingers, so the broadcast reaches every node once in O(log N) rounds.
+// Broadcast returns once every node acknowledged, with the acknowledgments aggregated along the way back.
+// Every round waits for its children a shorter time than its parent waits for it, so a slow subtree
+// is reported as failed on its own, without its ancestors timing out
+func (server *Server) Broadcast(payload []byte) (*BroadcastResult, error) {
+	messageID := make([]byte, 16)
+	if _, err := rand.Read(messageID); err != nil {
+		return nil, fmt.Errorf("Chord broadcast failed: %s", err)
+	}
+
+	// the origin covers the whole ring, up to and excluding itself
+	req := NewBroadcastRequest(messageID, payload, server.config.Host, server.config.Host, server.node.GetID())
+	resp := server.processBroadcastRequest(req)
+
+	result := &BroadcastResult{
+		Reached: resp.reached,
+		Rounds:  resp.rounds,
+		Failed:  make(map[string]string),
+	}
+	for i, host := range resp.failedHosts {
+		result.Failed[host] = resp.errors[i]
+	}
+	return result, nil
+}
+
+// processBroadcastRequest receives the broadcast of req, passes it on to the fingers in (node, limit),
+// each of them covering the part of the ring up to the next finger, and aggregates their acknowledgments
+func (server *Server) processBroadcastRequest(req *BroadcastRequest) *BroadcastResponse {
+	resp := &BroadcastResponse{rounds: req.round}
+	if !server.markBroadcast(req.MessageID) {
+		// the ring changed under the broadcast, and this node already has it
+		return resp
+	}
+
+	resp.reached = 1
+	if receiver, ok := server.Application().(BroadcastReceiver); ok {
+		msg := &Message{
+			Key:     req.MessageID,
+			Payload: req.Payload,
+			Source:  req.source,
+			Hops:    req.round,
+		}
+		if err := receiver.Receive(msg); err != nil {
+			resp.fail(server.config.Host, err)
+		}
+	}
+
+	// a child waits for its own children less than this node waits for it, which leaves time for the answer
+	// to come back, and this node answers before its parent stops waiting for it
+	childBudget := req.budget * 3 / 4
+	wait := req.budget * 7 / 8
+
+	children := server.broadcastChildren(req.Limit)
+	acks := make(chan broadcastAck, len(children))
+	for i, child := range children {
+		childReq := NewBroadcastRequest(req.MessageID, req.Payload, req.source, child.host, req.Limit)
+		childReq.round = req.round + 1
+		childReq.budget = childBudget
+		// every child covers the part of the ring up to the next child
+		if i+1 < len(children) {
+			childReq.Limit = children[i+1].ID
+		}
+
+		go func(i int) {
+			childResp, err := server.transporter.SendBroadcastRequest(server, childReq)
+			acks <- broadcastAck{index: i, resp: childResp, err: err}
+		}(i)
+	}
+
+	responses := make([]*BroadcastResponse, len(children))
+	errs := make([]error, len(children))
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+collect:
+	for range children {
+		select {
+		case ack := <-acks:
+			responses[ack.index], errs[ack.index] = ack.resp, ack.err
+		case <-timer.C:
+			break collect
+		}
+	}
+
+	for i, child := range children {
+		if errs[i] == nil && responses[i] == nil {
+			errs[i] = fmt.Errorf("no acknowledgment within %s", wait)
+		}
+		if errs[i] != nil {
+			log.Printf("[ERROR]broadcast.%s.%s", child.host, errs[i])
+			resp.fail(child.host, errs[i])
+			continue
+		}
+		childResp := responses[i]
+		resp.reached += childResp.reached
+		if childResp.rounds > resp.rounds {
+			resp.rounds = childResp.rounds
+		}
+		resp.failedHosts = append(resp.failedHosts, childResp.failedHosts...)
+		resp.errors = append(resp.errors, childResp.errors...)
+	}
+	return resp
+}
+
+// broadcastAck is the answer of the child at index to a broadcast request
+type broadcastAck struct {
+	index int
+	resp  *BroadcastResponse
+	err   error
+}
+
+// broadcastChildren returns the distinct successor and fingers of this node in (node, limit),
+// ordered by their distance from this node. A limit equal to this node's ID covers the whole ring
+func (server *Server) broadcastChildren(limit []byte) []*RemoteNode {
+	localNode := server.node
+	localID := localNode.GetID()
+	covers := func(id []byte) bool {
+		if bytes.Equal(id, localID) {
+			return false
+		}
+		if bytes.Equal(limit, localID) {
+			return true
+		}
+		return between(localID, limit, id)
+	}
+
+	seen := map[string]bool{}
+	children := []*RemoteNode{}
+	add := func(node *RemoteNode) {
+		if node == nil || node.host == "" || node.host == server.config.Host || seen[string(node.ID)] || !covers(node.ID) {
+			return
+		}
+		seen[string(node.ID)] = true
+		children = append(children, node)
+	}
+
+	// the successor covers the nodes the fingers skip while the finger table is not fixed yet
+	add(localNode.Successor())
+	for _, entry := range localNode.Finger() {
+		if entry != nil && entry.node != nil {
+			add(NewRemoteNode(entry.node, entry.host))
+		}
+	}
+
+	sort.Slice(children, func(i, j int) bool {
+		return between(localID, children[j].ID, children[i].ID)
+	})
+	return children
+}
+
+// markBroadcast records that this node received the broadcast with messageID,
+// and returns false if it had already received it
+func (server *Server) markBroadcast(messageID []byte) bool {
+	server.Lock()
+	defer server.Unlock()
+	id := string(messageID)
+	if server.seenBroadcasts[id] {
+		return false
+	}
+	server.seenBroadcasts[id] = true
+	server.seenBroadcastOrder = append(server.seenBroadcastOrder, id)
+	if len(server.seenBroadcastOrder) > maxSeenBroadcasts {
+		delete(server.seenBroadcasts, server.seenBroadcastOrder[0])
+		server.seenBroadcastOrder = server.seenBroadcastOrder[1:]
+	}
+	return true
+}
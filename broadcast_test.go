@@ -0,0 +1,218 @@
+package chord
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingReceiver counts the broadcasts a node receives
+type countingReceiver struct {
+	testApplication
+	sync.Mutex
+	received map[string]int
+	fail     bool
+}
+
+func (app *countingReceiver) Receive(msg *Message) error {
+	app.Lock()
+	defer app.Unlock()
+	app.received[string(msg.Payload)]++
+	if app.fail {
+		return errors.New("receive failed on purpose")
+	}
+	return nil
+}
+
+func (app *countingReceiver) count(payload string) int {
+	app.Lock()
+	defer app.Unlock()
+	return app.received[payload]
+}
+
+// newFingeredRing starts n nodes and waits until their successors, predecessors and fingers are all correct
+func newFingeredRing(t *testing.T, n int) []*testNode {
+	nodes := newTestRing(t, n, nil)
+	waitFingersFixed(t, nodes)
+	return nodes
+}
+
+// waitFingersFixed waits until the successors, predecessors and fingers of the nodes are all correct
+func waitFingersFixed(t *testing.T, nodes []*testNode) {
+	waitFor(t, 20*time.Second, "fingers to be fixed", func() bool {
+		snapshots := []*NodeSnapshot{}
+		for _, node := range nodes {
+			snapshots = append(snapshots, node.server.Snapshot())
+		}
+		return CheckRing(snapshots, 8).OK()
+	})
+}
+
+func TestBroadcastReachesEveryNodeOnce(t *testing.T) {
+	nodes := newFingeredRing(t, 8)
+	defer stopTestNodes(nodes)
+
+	apps := []*countingReceiver{}
+	for _, node := range nodes {
+		app := &countingReceiver{received: map[string]int{}}
+		apps = append(apps, app)
+		node.server.SetApplication(app)
+	}
+
+	result, err := nodes[3].server.Broadcast([]byte("config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reached != len(nodes) || len(result.Failed) != 0 {
+		t.Errorf("expected all %d nodes to acknowledge, got %d with failures %v", len(nodes), result.Reached, result.Failed)
+	}
+	for i, app := range apps {
+		if c := app.count("config"); c != 1 {
+			t.Errorf("expected node %d to receive the broadcast once, got %d", i, c)
+		}
+	}
+	// going around the successors takes N-1 rounds, the fingers halve the ring on every round
+	if result.Rounds >= len(nodes)-1 || result.Rounds > 8 {
+		t.Errorf("expected the broadcast to take O(log N) rounds, got %d", result.Rounds)
+	}
+}
+
+func TestBroadcastAggregatesFailures(t *testing.T) {
+	nodes := newFingeredRing(t, 4)
+	defer stopTestNodes(nodes)
+
+	for i, node := range nodes {
+		node.server.SetApplication(&countingReceiver{received: map[string]int{}, fail: i == 2})
+	}
+
+	result, err := nodes[0].server.Broadcast([]byte("invalidate"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reached != len(nodes) {
+		t.Errorf("expected all nodes to be reached, got %d", result.Reached)
+	}
+	failedHost := nodes[2].server.config.Host
+	if len(result.Failed) != 1 || result.Failed[failedHost] == "" {
+		t.Errorf("expected the failure of %s to come back to the origin, got %v", failedHost, result.Failed)
+	}
+}
+
+func TestBroadcastSingleNode(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	app := &countingReceiver{received: map[string]int{}}
+	nodes[0].server.SetApplication(app)
+
+	result, err := nodes[0].server.Broadcast([]byte("alone"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Reached != 1 || result.Rounds != 0 || app.count("alone") != 1 {
+		t.Errorf("expected the broadcast to reach only the origin, got %+v", result)
+	}
+}
+
+// broadcastSubtree returns the hosts a broadcast request to server covering the nodes up to limit reaches below it
+func broadcastSubtree(servers map[string]*Server, server *Server, limit []byte) []string {
+	hosts := []string{}
+	children := server.broadcastChildren(limit)
+	for i, child := range children {
+		childLimit := limit
+		if i+1 < len(children) {
+			childLimit = children[i+1].ID
+		}
+		hosts = append(hosts, child.host)
+		hosts = append(hosts, broadcastSubtree(servers, servers[child.host], childLimit)...)
+	}
+	return hosts
+}
+
+func TestBroadcastDelayedSubtree(t *testing.T) {
+	// every node sends its requests through its own FaultTransport, so that one node can be delayed
+	nodes := []*testNode{}
+	transports := map[string]*FaultTransport{}
+	ids := map[string]bool{}
+	for len(nodes) < 12 {
+		transport := NewFaultTransport(NewTransporter())
+		node := newTestNodes(t, 1, transport)[0]
+		if ids[string(node.server.node.ID)] {
+			stopTestNodes([]*testNode{node})
+			continue
+		}
+		ids[string(node.server.node.ID)] = true
+		transports[node.server.config.Host] = transport
+		nodes = append(nodes, node)
+	}
+	defer stopTestNodes(nodes)
+	joinTestRing(t, nodes)
+	waitFingersFixed(t, nodes)
+
+	apps := []*countingReceiver{}
+	servers := map[string]*Server{}
+	for _, node := range nodes {
+		app := &countingReceiver{received: map[string]int{}}
+		apps = append(apps, app)
+		node.server.SetApplication(app)
+		servers[node.server.config.Host] = node.server
+	}
+
+	// the origin has at most 8 distinct fingers, so one of its children passes the broadcast on
+	origin := nodes[0].server
+	var delayed *Server
+	var below []string
+	children := origin.broadcastChildren(origin.node.ID)
+	for i, child := range children {
+		limit := origin.node.ID
+		if i+1 < len(children) {
+			limit = children[i+1].ID
+		}
+		if below = broadcastSubtree(servers, servers[child.host], limit); len(below) > 0 {
+			delayed = servers[child.host]
+			break
+		}
+	}
+	if delayed == nil {
+		t.Fatal("expected a child of the origin to pass the broadcast on")
+	}
+
+	// the requests of the delayed node outlive the http timeout of the Transporter
+	transports[delayed.config.Host].SetFaults(FaultConfig{MinLatency: 1500 * time.Millisecond})
+	start := time.Now()
+	result, err := origin.Broadcast([]byte("slow"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the broadcast to return within its budget, took %s", elapsed)
+	}
+	if msg, ok := result.Failed[delayed.config.Host]; ok {
+		t.Errorf("expected the delayed node to acknowledge in time, got %s", msg)
+	}
+	if result.Reached < len(nodes)-len(below) {
+		t.Errorf("expected the nodes outside the delayed subtree to be reached, got %d of %d", result.Reached, len(nodes))
+	}
+	subtree := map[string]bool{}
+	for _, host := range below {
+		subtree[host] = true
+	}
+	for host := range result.Failed {
+		if !subtree[host] {
+			t.Errorf("expected only hosts below the delayed node to fail, got %s", host)
+		}
+	}
+	if len(result.Failed) == 0 {
+		t.Errorf("expected the children of the delayed node to be reported as failed")
+	}
+
+	// the delayed subtree still receives the broadcast once
+	waitFor(t, 5*time.Second, "the delayed subtree to receive the broadcast", func() bool {
+		for _, app := range apps {
+			if app.count("slow") != 1 {
+				return false
+			}
+		}
+		return true
+	})
+}
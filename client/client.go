@@ -0,0 +1,243 @@
+// Package client looks up the owners of keys in a Chord ring without joining it.
+//
+// A Client connects to one or more seed nodes, and caches the ranges owned by the nodes it has contacted
+// together with the successor and finger information of those nodes, so that most lookups take a single hop
+package client
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/wang502/chord"
+)
+
+// DefaultCacheTTL is the time a cached owned range is trusted before it is verified again
+const DefaultCacheTTL = 10 * time.Second
+
+// ErrNoSeeds is returned when a Client has no seed node to contact
+var ErrNoSeeds = errors.New("no seed nodes")
+
+// cachedRange represents a range of keys owned by a node, learned from its ownership endpoint
+type cachedRange struct {
+	owner   *chord.RemoteNode
+	keys    *chord.KeyRange
+	expires time.Time
+}
+
+// Client looks up the owners of keys in a Chord ring without joining it
+type Client struct {
+	seeds       []string
+	transporter *chord.Transporter
+	ttl         time.Duration
+
+	// ranges are the owned ranges of the nodes this client has contacted
+	ranges []*cachedRange
+	// nodes are the ring members learned from the successor and finger information of contacted nodes
+	nodes map[string]*chord.RemoteNode
+
+	sync.Mutex
+}
+
+// New initializes a Client contacting the ring through the given seed hosts
+func New(seeds ...string) *Client {
+	return &Client{
+		seeds:       seeds,
+		transporter: chord.NewTransporter(),
+		ttl:         DefaultCacheTTL,
+		nodes:       make(map[string]*chord.RemoteNode),
+	}
+}
+
+// NewRing initializes a Client looking up keys in the ring with ringID, for listeners hosting many rings
+func NewRing(ringID string, seeds ...string) *Client {
+	c := New(seeds...)
+	c.transporter = chord.NewRingTransporter(ringID)
+	return c
+}
+
+// SetAuthKeys sets the keys used to sign lookups when the ring requires authentication, the first key signs
+func (c *Client) SetAuthKeys(keys ...string) {
+	c.transporter.SetAuthKeys(keys)
+}
+
+// SetCacheTTL sets the time a cached owned range is trusted without being verified
+func (c *Client) SetCacheTTL(ttl time.Duration) {
+	c.Lock()
+	defer c.Unlock()
+	c.ttl = ttl
+}
+
+// Lookup returns the node owning key
+func (c *Client) Lookup(key []byte) (*chord.RemoteNode, error) {
+	if owner := c.cachedOwner(key); owner != nil {
+		return owner, nil
+	}
+
+	// a cached range past its ttl only needs the owner to confirm it, which takes a single hop
+	if owner := c.staleOwner(key); owner != nil {
+		if c.verify(owner, key) {
+			return owner, nil
+		}
+	}
+
+	errs := []string{}
+	for _, entry := range c.entryNodes(key) {
+		resp, err := c.transporter.SendFindSuccessorRequest(nil, chord.NewFindSuccessorRequest(key, entry))
+		if err != nil {
+			errs = append(errs, err.Error())
+			c.forget(entry)
+			continue
+		}
+
+		owner := chord.NewRemoteNode([]byte(resp.ID), resp.Host())
+		c.verify(owner, key)
+		return owner, nil
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("chord client lookup failed: %s", ErrNoSeeds)
+	}
+	return nil, fmt.Errorf("chord client lookup failed: %s", strings.Join(errs, "; "))
+}
+
+// Invalidate drops the cached range containing key, the next lookup of key goes through the ring again
+func (c *Client) Invalidate(key []byte) {
+	c.Lock()
+	defer c.Unlock()
+	c.dropRange(key)
+}
+
+// cachedOwner returns the owner of key from an unexpired cached range
+func (c *Client) cachedOwner(key []byte) *chord.RemoteNode {
+	c.Lock()
+	defer c.Unlock()
+	for _, r := range c.ranges {
+		if r.keys.Contains(key) && time.Now().Before(r.expires) {
+			return r.owner
+		}
+	}
+	return nil
+}
+
+// staleOwner returns the owner of key from an expired cached range
+func (c *Client) staleOwner(key []byte) *chord.RemoteNode {
+	c.Lock()
+	defer c.Unlock()
+	for _, r := range c.ranges {
+		if r.keys.Contains(key) {
+			return r.owner
+		}
+	}
+	return nil
+}
+
+// verify asks owner whether it owns key, and caches its owned range and routing information if it does.
+// The cached range containing key is invalidated if owner reports that it no longer owns key
+func (c *Client) verify(owner *chord.RemoteNode, key []byte) bool {
+	resp, err := c.transporter.GetOwnership(owner.Host(), [][]byte{key})
+	if err != nil {
+		c.Invalidate(key)
+		c.forget(owner.Host())
+		return false
+	}
+
+	keys, err := resp.Range()
+	if err != nil || !resp.Owned[fmt.Sprintf("%x", key)] {
+		c.Invalidate(key)
+		return false
+	}
+
+	c.Lock()
+	c.dropRange(key)
+	c.ranges = append(c.ranges, &cachedRange{
+		owner:   owner,
+		keys:    keys,
+		expires: time.Now().Add(c.ttl),
+	})
+	c.nodes[owner.Host()] = owner
+	c.Unlock()
+
+	c.learn(owner.Host())
+	return true
+}
+
+// learn caches the successor and finger information of the node on host
+func (c *Client) learn(host string) {
+	snapshot, err := c.transporter.GetSnapshot(host)
+	if err != nil {
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+	entries := append([]*chord.SnapshotEntry{snapshot.Successor, snapshot.Predecessor}, snapshot.Finger...)
+	for _, entry := range entries {
+		if entry != nil && entry.Host != "" {
+			c.nodes[entry.Host] = chord.NewRemoteNode(entry.ID, entry.Host)
+		}
+	}
+}
+
+// entryNodes returns the hosts to send a lookup of key to, the closest known node preceding key comes first,
+// followed by the seeds in random order
+func (c *Client) entryNodes(key []byte) []string {
+	c.Lock()
+	defer c.Unlock()
+
+	hosts := []string{}
+	var closest *chord.RemoteNode
+	for _, node := range c.nodes {
+		if bytes.Equal(node.ID, key) {
+			continue
+		}
+		if closest == nil {
+			closest = node
+			continue
+		}
+		// node is closer if it lies between the current closest node and key
+		r := &chord.KeyRange{Start: closest.ID, End: key, Known: true}
+		if r.Contains(node.ID) {
+			closest = node
+		}
+	}
+	if closest != nil {
+		hosts = append(hosts, closest.Host())
+	}
+
+	for _, i := range rand.Perm(len(c.seeds)) {
+		if closest == nil || c.seeds[i] != closest.Host() {
+			hosts = append(hosts, c.seeds[i])
+		}
+	}
+	return hosts
+}
+
+// forget drops a node that could not be contacted from the routing information
+func (c *Client) forget(host string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.nodes, host)
+	ranges := c.ranges[:0]
+	for _, r := range c.ranges {
+		if r.owner.Host() != host {
+			ranges = append(ranges, r)
+		}
+	}
+	c.ranges = ranges
+}
+
+// dropRange drops the cached ranges containing key, must be called with the lock held
+func (c *Client) dropRange(key []byte) {
+	ranges := c.ranges[:0]
+	for _, r := range c.ranges {
+		if !r.keys.Contains(key) {
+			ranges = append(ranges, r)
+		}
+	}
+	c.ranges = ranges
+}
@@ -0,0 +1,142 @@
+package client
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/wang502/chord"
+)
+
+// startRing starts n Chord servers with distinct IDs on in-process http servers and joins them into one ring
+func startRing(t *testing.T, n int) ([]*chord.Server, func()) {
+	servers := []*chord.Server{}
+	https := []*httptest.Server{}
+	ids := map[string]bool{}
+	for len(servers) < n {
+		router := mux.NewRouter()
+		ts := httptest.NewUnstartedServer(router)
+		config := chord.DefaultConfig("http://" + ts.Listener.Addr().String())
+		config.HashBits = 8
+		config.NumNodes = 256
+
+		transporter := chord.NewTransporter()
+		server := chord.NewServer(config.Host, config, transporter)
+		if ids[string(server.Snapshot().ID)] {
+			ts.Close()
+			continue
+		}
+		ids[string(server.Snapshot().ID)] = true
+
+		transporter.Install(server, router)
+		ts.Start()
+		if err := server.Start(); err != nil {
+			t.Fatal(err)
+		}
+		servers = append(servers, server)
+		https = append(https, ts)
+	}
+
+	for _, server := range servers[1:] {
+		if err := server.Join(servers[0].Snapshot().Host); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hosts := []string{}
+	for _, server := range servers {
+		hosts = append(hosts, server.Snapshot().Host)
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for !chord.CheckLiveRing(chord.NewTransporter(), hosts, 8).OK() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for ring to stabilize")
+		}
+		time.Sleep(chord.DefaultStabilizeInterval)
+	}
+
+	return servers, func() {
+		for _, server := range servers {
+			server.Stop()
+		}
+		for _, ts := range https {
+			ts.Close()
+		}
+	}
+}
+
+// owner returns the host of the true owner of key
+func owner(servers []*chord.Server, key []byte) string {
+	snapshots := []*chord.NodeSnapshot{}
+	for _, server := range servers {
+		snapshots = append(snapshots, server.Snapshot())
+	}
+	sort.Slice(snapshots, func(i, j int) bool { return bytes.Compare(snapshots[i].ID, snapshots[j].ID) < 0 })
+	for _, snapshot := range snapshots {
+		if bytes.Compare(snapshot.ID, key) >= 0 {
+			return snapshot.Host
+		}
+	}
+	return snapshots[0].Host
+}
+
+func TestLookup(t *testing.T) {
+	servers, stop := startRing(t, 4)
+	defer stop()
+
+	c := New(servers[0].Snapshot().Host)
+	for i := 0; i < 256; i += 13 {
+		key := []byte{byte(i)}
+		node, err := c.Lookup(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if node.Host() != owner(servers, key) {
+			t.Errorf("wrong owner %s of key %x, expected %s", node.Host(), key, owner(servers, key))
+		}
+	}
+
+	if len(c.nodes) < 2 {
+		t.Errorf("expected routing information to be cached, got %d nodes", len(c.nodes))
+	}
+}
+
+func TestLookupCacheInvalidation(t *testing.T) {
+	servers, stop := startRing(t, 2)
+	defer stop()
+
+	key := []byte{42}
+	c := New(servers[0].Snapshot().Host)
+	c.SetCacheTTL(0)
+	if _, err := c.Lookup(key); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.ranges) != 1 {
+		t.Fatalf("expected 1 cached range, got %d", len(c.ranges))
+	}
+
+	// pretend the range moved to the other node, its owner reports it no longer owns the key
+	right := c.ranges[0].owner.Host()
+	for _, server := range servers {
+		if server.Snapshot().Host != right {
+			c.ranges[0].owner = chord.NewRemoteNode(server.Snapshot().ID, server.Snapshot().Host)
+		}
+	}
+
+	node, err := c.Lookup(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if node.Host() != right {
+		t.Errorf("expected lookup to go through the ring after invalidation, got %s", node.Host())
+	}
+}
+
+func TestLookupNoSeeds(t *testing.T) {
+	if _, err := New().Lookup([]byte{1}); err == nil {
+		t.Error("expected error without seeds")
+	}
+}
@@ -1,7 +1,66 @@
 package chord
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// ErrUnknownCommand is returned when a command is not registered under its name
+var ErrUnknownCommand = errors.New("unknown command")
+
 // Command represents an interface to apply a command on a Chord server
 type Command interface {
 	CommandName() string
 	Apply(s *Server) (interface{}, error)
 }
+
+// commandTypes maps the names of the registered commands to their prototypes
+var commandTypes = make(map[string]Command)
+
+var commandTypesLock sync.RWMutex
+
+// RegisterCommand registers a command by its CommandName, so that it can be sent to and run on remote nodes.
+// Commands are sent as json, so their exported fields make up the command. Every node that runs a command
+// must register it, registering a nil command or a name twice panics
+func RegisterCommand(command Command) {
+	if command == nil {
+		panic("chord: cannot register nil command")
+	}
+	commandTypesLock.Lock()
+	defer commandTypesLock.Unlock()
+	if _, ok := commandTypes[command.CommandName()]; ok {
+		panic(fmt.Sprintf("chord: command %s already registered", command.CommandName()))
+	}
+	commandTypes[command.CommandName()] = command
+}
+
+// newCommand creates a new instance of the command registered under name, decoding its fields from data
+func newCommand(name string, data []byte) (Command, error) {
+	commandTypesLock.RLock()
+	command := commandTypes[name]
+	commandTypesLock.RUnlock()
+	if command == nil {
+		return nil, fmt.Errorf("%s: %s", ErrUnknownCommand, name)
+	}
+
+	// make a new instance of the registered type, whether it was registered as a pointer or a value
+	t := reflect.TypeOf(command)
+	var v reflect.Value
+	if t.Kind() == reflect.Ptr {
+		v = reflect.New(t.Elem())
+	} else {
+		v = reflect.New(t)
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, v.Interface()); err != nil {
+			return nil, fmt.Errorf("decode command %s failed: %s", name, err)
+		}
+	}
+	if t.Kind() == reflect.Ptr {
+		return v.Interface().(Command), nil
+	}
+	return v.Elem().Interface().(Command), nil
+}
@@ -1,12 +1,14 @@
 package chord
 
 import (
+	"crypto/ed25519"
 	"crypto/sha1"
 	"encoding/json"
 	"fmt"
 	"hash"
 	"io/ioutil"
 	"log"
+	"time"
 )
 
 // Config represents configuration for a Chord node
@@ -15,6 +17,107 @@ type Config struct {
 	HashFunc hash.Hash
 	HashBits int `json:"NumBits"`
 	NumNodes int `json:"NumNodes"`
+
+	// ProximityCandidates is the number of candidate nodes kept per finger for proximity neighbor selection,
+	// routing then prefers the candidate with the lowest round trip time. 0 or 1 disables it
+	ProximityCandidates int `json:"ProximityCandidates"`
+
+	// StabilizeInterval and MaxStabilizeInterval bound the adaptive interval of periodical stabilizing,
+	// it backs off from the min to the max while the ring does not change. 0 uses the defaults
+	StabilizeInterval    time.Duration `json:"StabilizeInterval"`
+	MaxStabilizeInterval time.Duration `json:"MaxStabilizeInterval"`
+
+	// FixFingerInterval and MaxFixFingerInterval bound the adaptive interval of fixing finger table,
+	// it backs off from the min to the max while the fingers do not change. 0 uses the defaults
+	FixFingerInterval    time.Duration `json:"FixFingerInterval"`
+	MaxFixFingerInterval time.Duration `json:"MaxFixFingerInterval"`
+
+	// LookupCacheTTL enables the lookup cache mapping ranges of IDs to their owners, entries expire after this TTL.
+	// LookupCacheSize is the max number of owners kept in the cache. 0 disables the cache
+	LookupCacheTTL  time.Duration `json:"LookupCacheTTL"`
+	LookupCacheSize int           `json:"LookupCacheSize"`
+
+	// AuthKeys are the shared secrets used to sign and verify every request and response between nodes with HMAC.
+	// The first key signs, every key verifies, so keys can be rotated by adding the new key first and removing the old one later.
+	// Empty disables authentication
+	AuthKeys []string `json:"AuthKeys"`
+
+	// AuthMaxSkew is the max difference between the timestamp of a signed request and the local clock,
+	// requests outside this window, or with a nonce already seen inside it, are rejected. 0 uses the default
+	AuthMaxSkew time.Duration `json:"AuthMaxSkew"`
+
+	// VerifyIdentity rejects nodes claiming an ID other than the hash of their host as predecessor or successor.
+	// IdentityVerifier replaces the hash check with another identity scheme
+	VerifyIdentity   bool             `json:"VerifyIdentity"`
+	IdentityVerifier IdentityVerifier `json:"-"`
+
+	// ProbeHosts pings a claimed host once before accepting it as predecessor or successor,
+	// and rejects it unless it answers with the claimed ID. Only usable where nodes can reach each other directly
+	ProbeHosts bool `json:"ProbeHosts"`
+
+	// Seeds are hosts of nodes to join the ring through, SeedFile lists more of them one host per line
+	Seeds    []string `json:"Seeds"`
+	SeedFile string   `json:"SeedFile"`
+
+	// JoinTimeout is the time JoinAny keeps retrying the seeds, JoinBackoff and MaxJoinBackoff bound the wait
+	// between rounds of seeds. 0 uses the defaults
+	JoinTimeout    time.Duration `json:"JoinTimeout"`
+	JoinBackoff    time.Duration `json:"JoinBackoff"`
+	MaxJoinBackoff time.Duration `json:"MaxJoinBackoff"`
+
+	// RingName separates rings on the same local network, Discovery only joins rings announced with the same name.
+	// DiscoveryGroup is the multicast address announcements are sent to, and DiscoveryInterval the time between them.
+	// Empty or 0 uses the defaults
+	RingName          string        `json:"RingName"`
+	DiscoveryGroup    string        `json:"DiscoveryGroup"`
+	DiscoveryInterval time.Duration `json:"DiscoveryInterval"`
+
+	// ConsistencyInterval enables the periodical check for split or looping rings, which looks up this node's ID
+	// through ConsistencyProbes random peers and re-joins through a peer whose lookup ends at another node.
+	// PeerCacheSize is the max number of peers remembered. 0 disables the check, or uses the defaults
+	ConsistencyInterval time.Duration `json:"ConsistencyInterval"`
+	ConsistencyProbes   int           `json:"ConsistencyProbes"`
+	PeerCacheSize       int           `json:"PeerCacheSize"`
+
+	// StatePath enables saving the successor, predecessor, finger table and peers of the node to this file
+	// every StateSaveInterval and on Stop. On Start, a node that is alone rejoins the ring through the saved hosts.
+	// Empty disables persistence, 0 uses the default interval
+	StatePath         string        `json:"StatePath"`
+	StateSaveInterval time.Duration `json:"StateSaveInterval"`
+
+	// ID is an explicit hex encoded node ID, which keeps the place of the node on the ring when its host changes.
+	// IdentityKeyPath is a file holding the ed25519 private key of the node, generated on first use,
+	// the ID is then the hash of its public key and pings are answered with a proof of the key.
+	// Both empty derive the ID from the host
+	ID              string `json:"ID"`
+	IdentityKeyPath string `json:"IdentityKeyPath"`
+	identityKey     ed25519.PrivateKey
+
+	// LeaveOnShutdown makes Shutdown hand the successor and predecessor of the node over to each other,
+	// instead of leaving the ring to notice the node is gone by stabilizing
+	LeaveOnShutdown bool `json:"LeaveOnShutdown"`
+
+	// HealthTimeout is the time the event loop has to answer a health check, ReadyStabilizeFailures the number
+	// of stabilizations in a row that may fail before the node is not ready. 0 uses the defaults
+	HealthTimeout          time.Duration `json:"HealthTimeout"`
+	ReadyStabilizeFailures int           `json:"ReadyStabilizeFailures"`
+
+	// QueueSize is the number of events each lane of the event queue holds before new events are rejected
+	// with ErrOverloaded, MaxQueuedPerSource the number of events one source may have queued in a lane.
+	// 0 uses the default size, and no limit per source
+	QueueSize          int `json:"QueueSize"`
+	MaxQueuedPerSource int `json:"MaxQueuedPerSource"`
+
+	// SizeGossipInterval enables averaging the estimated number of nodes in the ring with a random peer
+	// at this interval, which smooths the estimates of the nodes towards each other. 0 disables gossip
+	SizeGossipInterval time.Duration `json:"SizeGossipInterval"`
+
+	// LoadBalanceInterval enables load balancing by moving light nodes next to heavy ones at this interval.
+	// A node moves when its load is below LoadBalanceEpsilon times the load of a random peer.
+	// Load is the number of keys in the DataStore of the node, or the size of its range without one.
+	// 0 disables load balancing, or uses the default epsilon
+	LoadBalanceInterval time.Duration `json:"LoadBalanceInterval"`
+	LoadBalanceEpsilon  float64       `json:"LoadBalanceEpsilon"`
 }
 
 // InitConfig initializes configuration from conf file
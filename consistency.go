@@ -0,0 +1,122 @@
+package chord
+
+import (
+	"fmt"
+	"log"
+	"math/rand"
+	"time"
+)
+
+const (
+	// DefaultPeerCacheSize is the max number of peers remembered for consistency checks if not configured
+	DefaultPeerCacheSize = 64
+
+	// DefaultConsistencyProbes is the number of random peers that look up this node's ID in every consistency check
+	// if not configured
+	DefaultConsistencyProbes = 3
+)
+
+// AddPeers remembers hosts of other nodes, consistency checks look up this node's ID through them.
+// Peers should include nodes that may end up in another ring, such as the seeds
+func (server *Server) AddPeers(hosts ...string) {
+	server.Lock()
+	defer server.Unlock()
+	size := server.config.PeerCacheSize
+	if size <= 0 {
+		size = DefaultPeerCacheSize
+	}
+
+	for _, host := range hosts {
+		if host == "" || host == server.config.Host || server.peers[host] {
+			continue
+		}
+		if len(server.peers) >= size {
+			// evict a random peer, map iteration order is random enough
+			for evicted := range server.peers {
+				delete(server.peers, evicted)
+				break
+			}
+		}
+		server.peers[host] = true
+	}
+}
+
+// Peers returns the hosts of the peers remembered for consistency checks
+func (server *Server) Peers() []string {
+	server.RLock()
+	defer server.RUnlock()
+	hosts := []string{}
+	for host := range server.peers {
+		hosts = append(hosts, host)
+	}
+	return hosts
+}
+
+// forgetPeer drops a peer that could not be contacted
+func (server *Server) forgetPeer(host string) {
+	server.Lock()
+	defer server.Unlock()
+	delete(server.peers, host)
+}
+
+// samplePeers returns up to n peers in random order
+func (server *Server) samplePeers(n int) []string {
+	hosts := server.Peers()
+	rand.Shuffle(len(hosts), func(i, j int) { hosts[i], hosts[j] = hosts[j], hosts[i] })
+	if len(hosts) > n {
+		hosts = hosts[:n]
+	}
+	return hosts
+}
+
+// checkConsistency detects a split or looping ring by looking up this node's ID through random peers.
+// In a consistent ring every lookup of this node's ID ends at this node. A lookup ending at another node
+// means the peer routes in another ring, or around a loop, so this node re-joins through that peer,
+// and stabilizing merges the rings from there. It returns whether this node re-joined
+func (server *Server) checkConsistency() (bool, error) {
+	probes := server.config.ConsistencyProbes
+	if probes <= 0 {
+		probes = DefaultConsistencyProbes
+	}
+
+	for _, host := range server.samplePeers(probes) {
+		req := NewFindSuccessorRequest(server.node.GetID(), host)
+		req.Fresh = true
+		resp, err := server.transporter.SendFindSuccessorRequest(server, req)
+		if err != nil {
+			server.forgetPeer(host)
+			continue
+		}
+		if resp.host == server.config.Host {
+			continue
+		}
+
+		log.Printf("[Repair]host %s: lookup of own ID through %s ended at %s, re-joining", server.config.Host, host, resp.host)
+		if err = server.Join(host); err != nil {
+			return false, fmt.Errorf("Chord check consistency failed: %s", err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// startPeriodicalCheckConsistency checks the consistency of the ring every ConsistencyInterval until the server stops
+func (server *Server) startPeriodicalCheckConsistency() {
+	stopChan := server.stopChan
+	ticker := time.NewTicker(server.config.ConsistencyInterval)
+	defer ticker.Stop()
+
+	log.Printf("chord.PeriodicalCheckConsistency.host: %s.interval: %s", server.config.Host, server.config.ConsistencyInterval)
+
+	for {
+		select {
+		case <-stopChan:
+			log.Printf("chord.PeriodicalCheckConsistency.stop.%s", server.config.Host)
+			return
+		case <-ticker.C:
+			if _, err := server.checkConsistency(); err != nil {
+				log.Printf("[ERROR]%s.chord.PeriodicalCheckConsistency.error.%s", server.config.Host, err)
+			}
+		}
+	}
+}
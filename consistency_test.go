@@ -0,0 +1,70 @@
+package chord
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckConsistencyConsistentRing(t *testing.T) {
+	nodes := newTestRing(t, 3, nil)
+	defer stopTestNodes(nodes)
+
+	for _, node := range nodes {
+		if len(node.server.Peers()) == 0 {
+			t.Errorf("expected %s to remember peers", node.server.config.Host)
+		}
+		rejoined, err := node.server.checkConsistency()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if rejoined {
+			t.Errorf("expected %s to find the ring consistent", node.server.config.Host)
+		}
+	}
+}
+
+func TestCheckConsistencyMergesSplitRings(t *testing.T) {
+	nodes := newTestNodes(t, 6, nil)
+	defer stopTestNodes(nodes)
+
+	// two rings form, as if their nodes had joined through different seeds during a partition
+	left, right := nodes[:3], nodes[3:]
+	joinTestNodes(t, left)
+	joinTestNodes(t, right)
+	waitFor(t, 10*time.Second, "split rings to stabilize", func() bool {
+		return testRingStable(left) && testRingStable(right)
+	})
+
+	// one node of the left ring knows a node of the right ring, and restarts with the check enabled
+	server := left[0].server
+	server.AddPeers(right[0].server.config.Host)
+	for _, node := range nodes {
+		node.server.Stop()
+		node.server.config.ConsistencyInterval = 100 * time.Millisecond
+		if err := node.server.Start(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	waitFor(t, 20*time.Second, "split rings to merge", func() bool {
+		return testRingStable(nodes)
+	})
+}
+
+func TestAddPeersBounded(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[0].server
+	server.config.PeerCacheSize = 2
+
+	server.AddPeers(server.config.Host, "http://localhost:1", "http://localhost:2", "http://localhost:3", "")
+	peers := server.Peers()
+	if len(peers) != 2 {
+		t.Errorf("expected 2 peers, got %v", peers)
+	}
+	for _, host := range peers {
+		if host == server.config.Host || host == "" {
+			t.Errorf("expected only other hosts as peers, got %v", peers)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package chord
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultDiscoveryGroup is the multicast group announcements are sent to if not configured
+	DefaultDiscoveryGroup = "239.192.0.77:7946"
+
+	// DefaultDiscoveryInterval is the interval between two announcements if not configured
+	DefaultDiscoveryInterval = time.Second
+
+	// maxAnnouncementSize is the max size of an announcement datagram
+	maxAnnouncementSize = 2048
+)
+
+// ErrDiscoveryRunning is returned when starting a Discovery that is already running
+var ErrDiscoveryRunning = errors.New("discovery already running")
+
+// Announcement represents the message a node multicasts to be discovered on the local network
+type Announcement struct {
+	Ring string
+	Host string
+	ID   []byte
+
+	// InRing is set once the node has joined a ring, that is, it is not alone
+	InRing bool
+}
+
+// Discovery announces a node on a multicast group of the local network, listens for the announcements of other nodes,
+// and joins the first ring with the same name it hears about. It is meant for lab clusters and local development,
+// where hand-configuring join hosts is not worth it
+type Discovery struct {
+	server   *Server
+	ring     string
+	group    *net.UDPAddr
+	interval time.Duration
+
+	listener *net.UDPConn
+	sender   *net.UDPConn
+
+	stopChan     chan bool
+	routineGroup sync.WaitGroup
+	sync.Mutex
+}
+
+// NewDiscovery initializes the discovery of server, using the ring name, multicast group and interval in its config
+func NewDiscovery(server *Server) (*Discovery, error) {
+	group := server.config.DiscoveryGroup
+	if group == "" {
+		group = DefaultDiscoveryGroup
+	}
+	addr, err := net.ResolveUDPAddr("udp4", group)
+	if err != nil {
+		return nil, fmt.Errorf("Chord new discovery failed: %s", err)
+	}
+
+	return &Discovery{
+		server:   server,
+		ring:     server.config.RingName,
+		group:    addr,
+		interval: durationOrDefault(server.config.DiscoveryInterval, DefaultDiscoveryInterval),
+	}, nil
+}
+
+// Start starts announcing this node and listening for the announcements of other nodes
+func (d *Discovery) Start() error {
+	d.Lock()
+	defer d.Unlock()
+	if d.stopChan != nil {
+		return fmt.Errorf("Chord discovery start failed: %s", ErrDiscoveryRunning)
+	}
+
+	listener, err := net.ListenMulticastUDP("udp4", nil, d.group)
+	if err != nil {
+		return fmt.Errorf("Chord discovery start failed: %s", err)
+	}
+	sender, err := net.DialUDP("udp4", nil, d.group)
+	if err != nil {
+		listener.Close()
+		return fmt.Errorf("Chord discovery start failed: %s", err)
+	}
+	d.listener = listener
+	d.sender = sender
+	d.stopChan = make(chan bool)
+
+	d.routineGroup.Add(1)
+	go func() {
+		defer d.routineGroup.Done()
+		d.listen()
+	}()
+
+	d.routineGroup.Add(1)
+	go func() {
+		defer d.routineGroup.Done()
+		d.startPeriodicalAnnounce()
+	}()
+
+	log.Printf("[Discovery]host %s announcing ring %q on %s", d.server.config.Host, d.ring, d.group)
+	return nil
+}
+
+// Stop stops announcing and listening
+func (d *Discovery) Stop() error {
+	d.Lock()
+	defer d.Unlock()
+	if d.stopChan == nil {
+		return fmt.Errorf("Chord discovery stop failed: not running")
+	}
+
+	close(d.stopChan)
+	d.listener.Close()
+	d.sender.Close()
+	d.routineGroup.Wait()
+	d.stopChan = nil
+	return nil
+}
+
+// startPeriodicalAnnounce announces this node until discovery is stopped
+func (d *Discovery) startPeriodicalAnnounce() {
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := d.announce(); err != nil {
+			log.Printf("[ERROR]announce.error.%s", err)
+		}
+
+		select {
+		case <-d.stopChan:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// announce multicasts the announcement of this node
+func (d *Discovery) announce() error {
+	data, err := json.Marshal(&Announcement{
+		Ring:   d.ring,
+		Host:   d.server.config.Host,
+		ID:     d.server.node.GetID(),
+		InRing: !d.server.alone(),
+	})
+	if err != nil {
+		return fmt.Errorf("announce failed: %s", err)
+	}
+
+	if _, err = d.sender.Write(data); err != nil {
+		return fmt.Errorf("announce failed: %s", err)
+	}
+	return nil
+}
+
+// listen handles the announcements received on the multicast group until the listener is closed
+func (d *Discovery) listen() {
+	buf := make([]byte, maxAnnouncementSize)
+	for {
+		n, _, err := d.listener.ReadFromUDP(buf)
+		if err != nil {
+			select {
+			case <-d.stopChan:
+				return
+			default:
+			}
+			log.Printf("[ERROR]discovery.listen.error.%s", err)
+			continue
+		}
+
+		a := &Announcement{}
+		if err = json.Unmarshal(buf[:n], a); err != nil {
+			continue
+		}
+		d.handle(a)
+	}
+}
+
+// handle joins the ring of an announcing node while this node is alone.
+// Two nodes that are both alone would otherwise join each other, so an alone node only joins
+// a node that is already in a ring, or an alone node with a lower host, which then starts the ring
+func (d *Discovery) handle(a *Announcement) {
+	host := d.server.config.Host
+	if a.Ring != d.ring || a.Host == "" || a.Host == host {
+		return
+	}
+	// nodes of the same ring name are peers even once this node has joined, so that split rings are detected
+	d.server.AddPeers(a.Host)
+	if !d.server.alone() {
+		return
+	}
+	if !a.InRing && a.Host > host {
+		return
+	}
+
+	if err := d.server.Join(a.Host); err != nil {
+		log.Printf("[ERROR]discovery.join.%s.%s", a.Host, err)
+		return
+	}
+	log.Printf("[Discovery]host %s joined ring %q through %s", host, d.ring, a.Host)
+}
+
+// alone checks whether this node has neither a successor nor a predecessor other than itself
+func (server *Server) alone() bool {
+	host := server.config.Host
+	succ := server.node.Successor()
+	pred := server.node.Predecessor()
+	return (succ == nil || succ.host == host) && (pred == nil || pred.host == host)
+}
@@ -0,0 +1,71 @@
+package chord
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestDiscoveryHandle(t *testing.T) {
+	nodes := newTestNodes(t, 2, nil)
+	defer stopTestNodes(nodes)
+	low, high := nodes[0].server, nodes[1].server
+	if low.config.Host > high.config.Host {
+		low, high = high, low
+	}
+
+	discoveries := map[*Server]*Discovery{}
+	for _, server := range []*Server{low, high} {
+		server.config.RingName = "lab"
+		d, err := NewDiscovery(server)
+		if err != nil {
+			t.Fatal(err)
+		}
+		discoveries[server] = d
+	}
+
+	// announcements of other rings, and of higher alone nodes, are ignored
+	discoveries[high].handle(&Announcement{Ring: "other", Host: low.config.Host, ID: low.node.ID})
+	discoveries[low].handle(&Announcement{Ring: "lab", Host: high.config.Host, ID: high.node.ID})
+	if !low.alone() || !high.alone() {
+		t.Fatalf("expected nodes to stay alone")
+	}
+
+	discoveries[high].handle(&Announcement{Ring: "lab", Host: low.config.Host, ID: low.node.ID})
+	waitFor(t, 10*time.Second, "ring to stabilize", func() bool {
+		return testRingStable(nodes)
+	})
+}
+
+func TestDiscoveryMulticast(t *testing.T) {
+	nodes := newTestNodes(t, 4, nil)
+	defer stopTestNodes(nodes)
+
+	group := fmt.Sprintf("239.192.0.77:%d", 20000+rand.Intn(20000))
+	discoveries := []*Discovery{}
+	for i, node := range nodes {
+		// two rings share the group, and must not merge
+		node.server.config.RingName = fmt.Sprintf("ring-%d", i%2)
+		node.server.config.DiscoveryGroup = group
+		node.server.config.DiscoveryInterval = 50 * time.Millisecond
+		d, err := NewDiscovery(node.server)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = d.Start(); err != nil {
+			t.Skipf("multicast not available: %s", err)
+		}
+		defer d.Stop()
+		discoveries = append(discoveries, d)
+	}
+
+	rings := [][]*testNode{{nodes[0], nodes[2]}, {nodes[1], nodes[3]}}
+	waitFor(t, 10*time.Second, "discovered rings to stabilize", func() bool {
+		return testRingStable(rings[0]) && testRingStable(rings[1])
+	})
+
+	if err := discoveries[0].Start(); err == nil {
+		t.Errorf("expected starting a running discovery to fail")
+	}
+}
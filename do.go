@@ -0,0 +1,202 @@
+package chord
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/wang502/chord/protobuf"
+)
+
+// maxDoHops bounds how often a command is forwarded towards the owner of its key while the ring is changing
+const maxDoHops = 4
+
+// DoRequest represents a serialized command sent to run on the node that owns Key,
+// or on the target node if Key is empty
+type DoRequest struct {
+	Key        []byte
+	name       string
+	command    []byte
+	targetHost string
+	hops       int
+
+	// source is the address of the hop the request came from, or localSource for DoAt, the owner queues
+	// the command under it. It is not sent, a node can not pick the share of the queue it is counted against
+	source string
+}
+
+// DoResponse represents the json encoded result of a command, and the node it ran on
+type DoResponse struct {
+	ID     []byte
+	host   string
+	Result []byte
+	err    string
+}
+
+// NewDoRequest initializes a new request to run the command registered under name, with its json encoded fields,
+// on the node owning key, sent to targetHost
+func NewDoRequest(key []byte, name string, command []byte, targetHost string) *DoRequest {
+	return &DoRequest{
+		Key:        key,
+		name:       name,
+		command:    command,
+		targetHost: targetHost,
+	}
+}
+
+// NewDoResponse initializes a new response of the node with id on host
+func NewDoResponse(id []byte, host string) *DoResponse {
+	return &DoResponse{
+		ID:   id,
+		host: host,
+	}
+}
+
+// Encode encodes DoRequest into data buffer
+func (req *DoRequest) Encode(w io.Writer) (int, error) {
+	pbReq := &pb.DoRequest{
+		Key:        req.Key,
+		Name:       req.name,
+		Command:    req.command,
+		TargetHost: req.targetHost,
+		Hops:       int32(req.hops),
+	}
+	data, err := proto.Marshal(pbReq)
+	if err != nil {
+		return -1, fmt.Errorf("encode DoRequest failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes data from buffer and stores it in DoRequest
+func (req *DoRequest) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode DoRequest failed: %s", err)
+	}
+
+	pbReq := &pb.DoRequest{}
+	if err = proto.Unmarshal(data, pbReq); err != nil {
+		return -1, fmt.Errorf("decode DoRequest failed: %s", err)
+	}
+
+	req.Key = pbReq.Key
+	req.name = pbReq.Name
+	req.command = pbReq.Command
+	req.targetHost = pbReq.TargetHost
+	req.hops = int(pbReq.Hops)
+	return len(data), nil
+}
+
+// Encode encodes DoResponse into data buffer
+func (resp *DoResponse) Encode(w io.Writer) (int, error) {
+	pbResp := &pb.DoResponse{
+		ID:     resp.ID,
+		Host:   resp.host,
+		Result: resp.Result,
+		Error:  resp.err,
+	}
+	data, err := proto.Marshal(pbResp)
+	if err != nil {
+		return -1, fmt.Errorf("encode DoResponse failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes data from buffer and stores it in DoResponse
+func (resp *DoResponse) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode DoResponse failed: %s", err)
+	}
+
+	pbResp := &pb.DoResponse{}
+	if err = proto.Unmarshal(data, pbResp); err != nil {
+		return -1, fmt.Errorf("decode DoResponse failed: %s", err)
+	}
+
+	resp.ID = pbResp.ID
+	resp.host = pbResp.Host
+	resp.Result = pbResp.Result
+	resp.err = pbResp.Error
+	return len(data), nil
+}
+
+// DoAt runs command in the event loop of the node owning key, and decodes its json encoded result into result
+// unless result is nil. The command must be registered with RegisterCommand on the owner.
+// It returns the node the command ran on, also when the command failed
+func (server *Server) DoAt(key []byte, command Command, result interface{}) (*RemoteNode, error) {
+	data, err := json.Marshal(command)
+	if err != nil {
+		return nil, fmt.Errorf("Chord do failed: %s", err)
+	}
+
+	req := NewDoRequest(key, command.CommandName(), data, server.config.Host)
+	req.source = localSource
+	resp, err := server.processDoRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	owner := NewRemoteNode(resp.ID, resp.host)
+	if resp.err != "" {
+		return owner, fmt.Errorf("Chord do failed: %s on %s", resp.err, resp.host)
+	}
+	if result != nil && len(resp.Result) > 0 {
+		if err = json.Unmarshal(resp.Result, result); err != nil {
+			return owner, fmt.Errorf("Chord do failed: decode result: %s", err)
+		}
+	}
+	return owner, nil
+}
+
+// processDoRequest runs the command of req if this node owns its key, and forwards it to the owner otherwise.
+// Failing to reach the owner or ErrOverloaded is returned as error, the command failing is returned in the response
+func (server *Server) processDoRequest(req *DoRequest) (*DoResponse, error) {
+	if len(req.Key) > 0 && !server.Owns(req.Key) {
+		if req.hops >= maxDoHops {
+			return nil, fmt.Errorf("Chord do failed: owner of %x not reached within %d hops", req.Key, maxDoHops)
+		}
+		findReq := NewFindSuccessorRequest(req.Key, server.config.Host)
+		// a forwarded command was sent to a node that did not own the key, so the cached owners may be stale
+		findReq.Fresh = req.hops > 0
+		findResp, err := server.FindSuccessor(findReq)
+		if err != nil {
+			return nil, fmt.Errorf("Chord do failed: %s", err)
+		}
+		if findResp.host != server.config.Host {
+			forward := *req
+			forward.targetHost = findResp.host
+			forward.hops++
+			resp, err := server.transporter.SendDoRequest(server, &forward)
+			if err != nil {
+				return nil, fmt.Errorf("Chord do failed: %s", err)
+			}
+			return resp, nil
+		}
+	}
+
+	resp := NewDoResponse(server.node.GetID(), server.config.Host)
+	command, err := newCommand(req.name, req.command)
+	if err != nil {
+		resp.err = err.Error()
+		return resp, nil
+	}
+	res, err := server.sendCommand(&sourcedCommand{Command: command, source: req.source})
+	if err == ErrOverloaded {
+		return nil, err
+	}
+	if err != nil {
+		resp.err = err.Error()
+		return resp, nil
+	}
+	if res != nil {
+		if resp.Result, err = json.Marshal(res); err != nil {
+			resp.err = fmt.Sprintf("encode result failed: %s", err)
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,132 @@
+package chord
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoCommand answers with its text and the host it ran on
+type echoCommand struct {
+	Text string
+}
+
+func (c *echoCommand) CommandName() string {
+	return "test.echo"
+}
+
+func (c *echoCommand) Apply(s *Server) (interface{}, error) {
+	return map[string]string{"text": c.Text, "host": s.config.Host}, nil
+}
+
+// failCommand always fails
+type failCommand struct{}
+
+func (c failCommand) CommandName() string {
+	return "test.fail"
+}
+
+func (c failCommand) Apply(s *Server) (interface{}, error) {
+	return nil, errors.New("failed on purpose")
+}
+
+// unregisteredCommand is never registered
+type unregisteredCommand struct{}
+
+func (c *unregisteredCommand) CommandName() string {
+	return "test.unregistered"
+}
+
+func (c *unregisteredCommand) Apply(s *Server) (interface{}, error) {
+	return nil, nil
+}
+
+func init() {
+	RegisterCommand(&echoCommand{})
+	RegisterCommand(failCommand{})
+}
+
+func TestDoAtRoutesToOwner(t *testing.T) {
+	nodes := newTestRing(t, 4, nil)
+	defer stopTestNodes(nodes)
+
+	for k := 0; k < 256; k += 17 {
+		key := []byte{byte(k)}
+		if k == 0 {
+			key = []byte{}
+		}
+		expected := ""
+		for _, node := range nodes {
+			if node.server.Owns(key) {
+				expected = node.server.config.Host
+			}
+		}
+		if len(key) == 0 {
+			expected = nodes[1].server.config.Host
+		}
+
+		result := map[string]string{}
+		owner, err := nodes[1].server.DoAt(key, &echoCommand{Text: "hello"}, &result)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if owner.host != expected || result["host"] != expected {
+			t.Errorf("expected command on %x to run on %s, ran on %s answered by %s", key, expected, result["host"], owner.host)
+		}
+		if result["text"] != "hello" {
+			t.Errorf("expected command fields to be sent, got %q", result["text"])
+		}
+	}
+}
+
+func TestDoAtCommandErrors(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	defer stopTestNodes(nodes)
+
+	// run on the other node, so that the error crosses the wire
+	key := nodes[1].server.node.ID
+	owner, err := nodes[0].server.DoAt(key, failCommand{}, nil)
+	if err == nil || !strings.Contains(err.Error(), "failed on purpose") {
+		t.Errorf("expected the error of the command, got %v", err)
+	}
+	if owner == nil || owner.host != nodes[1].server.config.Host {
+		t.Errorf("expected the owner to be returned with the error of the command")
+	}
+
+	_, err = nodes[0].server.DoAt(key, &unregisteredCommand{}, nil)
+	if err == nil || !strings.Contains(err.Error(), ErrUnknownCommand.Error()) {
+		t.Errorf("expected unregistered command to be rejected, got %v", err)
+	}
+}
+
+func TestDoAtQueuesUnderSendingHop(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	defer stopTestNodes(nodes)
+	owner := nodes[1].server
+	key := owner.node.ID
+
+	// keep the event loop of the owner busy, so that the commands after it stay queued
+	go owner.Do(&slowCommand{delay: 300 * time.Millisecond})
+	time.Sleep(20 * time.Millisecond)
+	for _, server := range []*Server{nodes[0].server, nodes[0].server, owner} {
+		go server.DoAt(key, &echoCommand{Text: "queued"}, nil)
+	}
+
+	waitFor(t, time.Second, "commands to be queued", func() bool {
+		return owner.QueueStats().Lanes["commands"].Depth == 3
+	})
+	sources := owner.QueueStats().Sources
+	if sources[localSource] != 1 || sources["127.0.0.1"] != 2 {
+		t.Errorf("expected the commands to be queued under the sending address and the local process, got %v", sources)
+	}
+}
+
+func TestRegisterCommandTwicePanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expected registering a command name twice to panic")
+		}
+	}()
+	RegisterCommand(&echoCommand{})
+}
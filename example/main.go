@@ -46,6 +46,11 @@ func main() {
 		chordServer := chord.NewServer("TestNode"+ports[i], chord.DefaultConfig(host+ports[i]), transporter)
 		router := mux.NewRouter()
 		transporter.Install(chordServer, router)
+		// the example serves the admin API next to the peer protocol without a token,
+		// a deployment should bind it to a separate listener such as localhost and set a token
+		chord.NewAdmin(chordServer, "").Install(router)
+		chord.NewHealthHandler(chordServer).Install(router)
+		chord.NewMetricsHandler(chordServer).Install(router)
 
 		server := &http.Server{Addr: ports[i], Handler: router}
 		listener, err := net.Listen("tcp", ports[i])
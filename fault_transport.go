@@ -0,0 +1,462 @@
+package chord
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var (
+	// ErrFaultDropped is returned when a FaultTransport drops a request
+	ErrFaultDropped = errors.New("request dropped by fault injection")
+
+	// ErrFaultTimeout is returned when a FaultTransport times out a request
+	ErrFaultTimeout = errors.New("request timed out by fault injection")
+
+	// ErrFaultPartitioned is returned when the sender and the target are in different partition groups
+	ErrFaultPartitioned = errors.New("hosts are partitioned by fault injection")
+)
+
+// FaultConfig describes the faults a FaultTransport injects into every request.
+// Rates are probabilities between 0 and 1
+type FaultConfig struct {
+	// MinLatency and MaxLatency bound the uniformly distributed latency added to every request
+	MinLatency time.Duration
+	MaxLatency time.Duration
+
+	// DropRate is the probability that a request is dropped before it is sent
+	DropRate float64
+
+	// TimeoutRate is the probability that a request is sent but its response is lost,
+	// the caller gets ErrFaultTimeout after Timeout
+	TimeoutRate float64
+	Timeout     time.Duration
+
+	// DuplicateRate is the probability that a request is delivered twice
+	DuplicateRate float64
+
+	// ReorderRate is the probability that a request is held back until a later request to the same host
+	// has been delivered, so that the later request overtakes it. ReorderDelay bounds how long it is held
+	// if no other request follows
+	ReorderRate  float64
+	ReorderDelay time.Duration
+}
+
+// FaultTransport is a Transport decorator that injects faults into the requests sent by an inner Transport.
+// It is meant for chaos testing, and can be controlled at runtime from tests or through its http endpoints
+type FaultTransport struct {
+	inner      Transport
+	faults     FaultConfig
+	partitions map[string][][]string
+	rand       *rand.Rand
+
+	// held maps the hosts to the requests held back until the next request to them is delivered
+	held map[string][]chan struct{}
+	sync.Mutex
+}
+
+// faultPlan is the set of faults decided for a single request
+type faultPlan struct {
+	latency   time.Duration
+	drop      bool
+	timeout   bool
+	duplicate bool
+	// hold is the longest the request waits for a later request to overtake it, 0 if it is not held back
+	hold time.Duration
+}
+
+// NewFaultTransport initializes a FaultTransport wrapping inner, with no fault configured
+func NewFaultTransport(inner Transport) *FaultTransport {
+	return &FaultTransport{
+		inner:      inner,
+		partitions: make(map[string][][]string),
+		held:       make(map[string][]chan struct{}),
+		rand:       rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// -------------------------------------------------------------------------
+//
+// Fault control
+//
+// -------------------------------------------------------------------------
+
+// SetFaults replaces the faults injected into every request
+func (t *FaultTransport) SetFaults(faults FaultConfig) {
+	t.Lock()
+	defer t.Unlock()
+	t.faults = faults
+}
+
+// Faults returns the faults currently injected into every request
+func (t *FaultTransport) Faults() FaultConfig {
+	t.Lock()
+	defer t.Unlock()
+	return t.faults
+}
+
+// Partition installs a named network partition, hosts in different groups can not reach each other.
+// Hosts not listed in any group are not affected by this partition
+func (t *FaultTransport) Partition(name string, groups ...[]string) {
+	t.Lock()
+	defer t.Unlock()
+	t.partitions[name] = groups
+}
+
+// Heal removes the named network partition
+func (t *FaultTransport) Heal(name string) {
+	t.Lock()
+	defer t.Unlock()
+	delete(t.partitions, name)
+}
+
+// HealAll removes all network partitions
+func (t *FaultTransport) HealAll() {
+	t.Lock()
+	defer t.Unlock()
+	t.partitions = make(map[string][][]string)
+}
+
+// Reset removes all network partitions and faults
+func (t *FaultTransport) Reset() {
+	t.Lock()
+	defer t.Unlock()
+	t.faults = FaultConfig{}
+	t.partitions = make(map[string][][]string)
+}
+
+// Partitioned checks whether any partition separates the two hosts
+func (t *FaultTransport) Partitioned(from string, to string) bool {
+	t.Lock()
+	defer t.Unlock()
+	for _, groups := range t.partitions {
+		fromGroup, toGroup := partitionGroup(groups, from), partitionGroup(groups, to)
+		if fromGroup >= 0 && toGroup >= 0 && fromGroup != toGroup {
+			return true
+		}
+	}
+	return false
+}
+
+// partitionGroup returns the index of the group containing host, or -1
+func partitionGroup(groups [][]string, host string) int {
+	for i, group := range groups {
+		for _, h := range group {
+			if h == host {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// plan decides the faults to inject into a single request
+func (t *FaultTransport) plan() (faultPlan, time.Duration) {
+	t.Lock()
+	defer t.Unlock()
+	f := t.faults
+	p := faultPlan{latency: f.MinLatency}
+	if f.MaxLatency > f.MinLatency {
+		p.latency += time.Duration(t.rand.Int63n(int64(f.MaxLatency - f.MinLatency)))
+	}
+	if f.ReorderRate > 0 && t.rand.Float64() < f.ReorderRate {
+		p.hold = f.ReorderDelay
+	}
+	p.drop = f.DropRate > 0 && t.rand.Float64() < f.DropRate
+	p.timeout = f.TimeoutRate > 0 && t.rand.Float64() < f.TimeoutRate
+	p.duplicate = f.DuplicateRate > 0 && t.rand.Float64() < f.DuplicateRate
+	return p, f.Timeout
+}
+
+// deliver runs send from host "from" to host "to" with the faults planned for this request
+func (t *FaultTransport) deliver(from string, to string, send func() (interface{}, error)) (interface{}, error) {
+	if t.Partitioned(from, to) {
+		return nil, fmt.Errorf("%s -> %s: %w", from, to, ErrFaultPartitioned)
+	}
+
+	p, timeout := t.plan()
+	time.Sleep(p.latency)
+
+	if p.drop {
+		return nil, fmt.Errorf("%s -> %s: %w", from, to, ErrFaultDropped)
+	}
+	if p.duplicate {
+		go send()
+	}
+	if p.hold > 0 {
+		t.holdBack(to, p.hold)
+	}
+	if p.timeout {
+		go send()
+		time.Sleep(timeout)
+		return nil, fmt.Errorf("%s -> %s: %w", from, to, ErrFaultTimeout)
+	}
+	res, err := send()
+	t.release(to)
+	return res, err
+}
+
+// holdBack blocks until a later request to host has been delivered, or for at most max
+func (t *FaultTransport) holdBack(host string, max time.Duration) {
+	released := make(chan struct{})
+	t.Lock()
+	t.held[host] = append(t.held[host], released)
+	t.Unlock()
+
+	timer := time.NewTimer(max)
+	defer timer.Stop()
+	select {
+	case <-released:
+	case <-timer.C:
+		t.Lock()
+		defer t.Unlock()
+		held := t.held[host]
+		for i, c := range held {
+			if c == released {
+				t.held[host] = append(held[:i:i], held[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// release lets the requests held back for host go, once a request overtook them
+func (t *FaultTransport) release(host string) {
+	t.Lock()
+	held := t.held[host]
+	delete(t.held, host)
+	t.Unlock()
+	for _, released := range held {
+		close(released)
+	}
+}
+
+// faultSender returns the host of the server sending a request
+func faultSender(server *Server) string {
+	if server == nil {
+		return ""
+	}
+	return server.config.Host
+}
+
+// -------------------------------------------------------------------------
+//
+// Sending request
+//
+// -------------------------------------------------------------------------
+
+// SendFindSuccessorRequest sends a find successor request through the inner Transport with faults injected
+func (t *FaultTransport) SendFindSuccessorRequest(server *Server, req *FindSuccessorRequest) (*FindSuccessorResponse, error) {
+	res, err := t.deliver(faultSender(server), req.host, func() (interface{}, error) {
+		return t.inner.SendFindSuccessorRequest(server, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*FindSuccessorResponse), nil
+}
+
+// SendBatchFindSuccessorRequest sends a batch find successor request through the inner Transport with faults injected
+func (t *FaultTransport) SendBatchFindSuccessorRequest(server *Server, req *BatchFindSuccessorRequest) (*BatchFindSuccessorResponse, error) {
+	res, err := t.deliver(faultSender(server), req.host, func() (interface{}, error) {
+		return t.inner.SendBatchFindSuccessorRequest(server, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*BatchFindSuccessorResponse), nil
+}
+
+// SendNotifyRequest sends a notify request through the inner Transport with faults injected
+func (t *FaultTransport) SendNotifyRequest(server *Server, req *NotifyRequest) (*NotifyResponse, error) {
+	res, err := t.deliver(faultSender(server), req.targetHost, func() (interface{}, error) {
+		return t.inner.SendNotifyRequest(server, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*NotifyResponse), nil
+}
+
+// SendGetPredecessorRequest sends a get predecessor request through the inner Transport with faults injected
+func (t *FaultTransport) SendGetPredecessorRequest(server *Server, host string) (*GetPredecessorResponse, error) {
+	res, err := t.deliver(faultSender(server), host, func() (interface{}, error) {
+		return t.inner.SendGetPredecessorRequest(server, host)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*GetPredecessorResponse), nil
+}
+
+// SendGetSuccessorRequest sends a get successor request through the inner Transport with faults injected
+func (t *FaultTransport) SendGetSuccessorRequest(server *Server, host string) (*FindSuccessorResponse, error) {
+	res, err := t.deliver(faultSender(server), host, func() (interface{}, error) {
+		return t.inner.SendGetSuccessorRequest(server, host)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*FindSuccessorResponse), nil
+}
+
+// SendPingRequest sends a ping through the inner Transport with faults injected
+func (t *FaultTransport) SendPingRequest(server *Server, host string, challenge []byte) (*PingResponse, error) {
+	res, err := t.deliver(faultSender(server), host, func() (interface{}, error) {
+		return t.inner.SendPingRequest(server, host, challenge)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*PingResponse), nil
+}
+
+// SendLeaveRequest sends a leave request through the inner Transport with faults injected
+func (t *FaultTransport) SendLeaveRequest(server *Server, req *LeaveRequest) error {
+	_, err := t.deliver(faultSender(server), req.targetHost, func() (interface{}, error) {
+		return nil, t.inner.SendLeaveRequest(server, req)
+	})
+	return err
+}
+
+// SendDoRequest sends a command through the inner Transport with faults injected
+func (t *FaultTransport) SendDoRequest(server *Server, req *DoRequest) (*DoResponse, error) {
+	res, err := t.deliver(faultSender(server), req.targetHost, func() (interface{}, error) {
+		return t.inner.SendDoRequest(server, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*DoResponse), nil
+}
+
+// SendRouteRequest sends an application message through the inner Transport with faults injected
+func (t *FaultTransport) SendRouteRequest(server *Server, req *RouteRequest) (*RouteResponse, error) {
+	res, err := t.deliver(faultSender(server), req.targetHost, func() (interface{}, error) {
+		return t.inner.SendRouteRequest(server, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*RouteResponse), nil
+}
+
+// SendBroadcastRequest sends a broadcast through the inner Transport with faults injected
+func (t *FaultTransport) SendBroadcastRequest(server *Server, req *BroadcastRequest) (*BroadcastResponse, error) {
+	res, err := t.deliver(faultSender(server), req.targetHost, func() (interface{}, error) {
+		return t.inner.SendBroadcastRequest(server, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*BroadcastResponse), nil
+}
+
+// SendSizeRequest gossips a size estimate through the inner Transport with faults injected
+func (t *FaultTransport) SendSizeRequest(server *Server, host string, share float64) (*SizeResponse, error) {
+	res, err := t.deliver(faultSender(server), host, func() (interface{}, error) {
+		return t.inner.SendSizeRequest(server, host, share)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*SizeResponse), nil
+}
+
+// SendLoadRequest fetches the load of a node through the inner Transport with faults injected
+func (t *FaultTransport) SendLoadRequest(server *Server, host string) (*LoadResponse, error) {
+	res, err := t.deliver(faultSender(server), host, func() (interface{}, error) {
+		return t.inner.SendLoadRequest(server, host)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*LoadResponse), nil
+}
+
+// SendTransferRequest moves data through the inner Transport with faults injected
+func (t *FaultTransport) SendTransferRequest(server *Server, req *TransferRequest) (*TransferResponse, error) {
+	res, err := t.deliver(faultSender(server), req.targetHost, func() (interface{}, error) {
+		return t.inner.SendTransferRequest(server, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res.(*TransferResponse), nil
+}
+
+//	-------------------------------------------------------------------------
+//
+//	handler functions
+//
+//	-------------------------------------------------------------------------
+
+// Install applies the fault control routes to an http router, as admin actions of admin: they require
+// the admin token and are recorded in its audit log like the other admin actions
+// - GET  /fault returns the current faults and partitions
+// - POST /fault sets the faults from a json encoded FaultConfig
+// - POST /fault/partition?name=&group=host1,host2&group=host3 installs a named partition
+// - POST /fault/heal?name= removes a named partition, or all partitions if name is empty
+func (t *FaultTransport) Install(admin *Admin, mux *mux.Router) {
+	mux.HandleFunc("/fault", admin.handle("faults", t.getFaultsHandler)).Methods("GET")
+	mux.HandleFunc("/fault", admin.handle("fault", t.setFaultsHandler)).Methods("POST")
+	mux.HandleFunc("/fault/partition", admin.handle("partition", t.partitionHandler)).Methods("POST")
+	mux.HandleFunc("/fault/heal", admin.handle("heal", t.healHandler)).Methods("POST")
+}
+
+// getFaultsHandler handles incoming request to return the current faults and partitions
+func (t *FaultTransport) getFaultsHandler(r *http.Request) (string, string, error) {
+	t.Lock()
+	status := struct {
+		Faults     FaultConfig
+		Partitions map[string][][]string
+	}{t.faults, t.partitions}
+	data, err := json.Marshal(status)
+	t.Unlock()
+	if err != nil {
+		return "", "failed to encode faults", err
+	}
+	return "", string(data), nil
+}
+
+// setFaultsHandler handles incoming request to replace the current faults
+func (t *FaultTransport) setFaultsHandler(r *http.Request) (string, string, error) {
+	faults := FaultConfig{}
+	if err := json.NewDecoder(r.Body).Decode(&faults); err != nil {
+		return "", fmt.Sprintf("failed to decode faults.%s", err), err
+	}
+	t.SetFaults(faults)
+	return "", "success to set faults", nil
+}
+
+// partitionHandler handles incoming request to install a named partition
+func (t *FaultTransport) partitionHandler(r *http.Request) (string, string, error) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		return "", "missing partition name", errors.New("missing partition name")
+	}
+	groups := [][]string{}
+	for _, group := range r.URL.Query()["group"] {
+		groups = append(groups, strings.Split(group, ","))
+	}
+	t.Partition(name, groups...)
+	return name, fmt.Sprintf("success to partition %s", name), nil
+}
+
+// healHandler handles incoming request to remove a named partition
+func (t *FaultTransport) healHandler(r *http.Request) (string, string, error) {
+	name := r.URL.Query().Get("name")
+	if name == "" {
+		t.HealAll()
+	} else {
+		t.Heal(name)
+	}
+	return name, fmt.Sprintf("success to heal %s", name), nil
+}
@@ -0,0 +1,241 @@
+package chord
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// countingTransport is a Transport that answers lookups locally and counts them
+type countingTransport struct {
+	nopTransport
+	calls int
+	// lookedUp holds the IDs of the lookups in the order they were delivered
+	lookedUp []string
+	sync.Mutex
+}
+
+func (t *countingTransport) count() {
+	t.Lock()
+	defer t.Unlock()
+	t.calls++
+}
+
+func (t *countingTransport) Calls() int {
+	t.Lock()
+	defer t.Unlock()
+	return t.calls
+}
+
+func (t *countingTransport) SendFindSuccessorRequest(server *Server, req *FindSuccessorRequest) (*FindSuccessorResponse, error) {
+	t.count()
+	t.Lock()
+	t.lookedUp = append(t.lookedUp, req.ID)
+	t.Unlock()
+	return &FindSuccessorResponse{ID: req.ID, host: req.host}, nil
+}
+
+func (t *countingTransport) SendGetPredecessorRequest(server *Server, host string) (*GetPredecessorResponse, error) {
+	t.count()
+	return &GetPredecessorResponse{ID: host, host: host}, nil
+}
+
+func TestFaultTransportPassThrough(t *testing.T) {
+	inner := &countingTransport{}
+	ft := NewFaultTransport(inner)
+
+	resp, err := ft.SendFindSuccessorRequest(nil, NewFindSuccessorRequest([]byte("key"), "host1"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.host != "host1" || inner.Calls() != 1 {
+		t.Error("request not passed through to inner transport")
+	}
+}
+
+func TestFaultTransportDrop(t *testing.T) {
+	inner := &countingTransport{}
+	ft := NewFaultTransport(inner)
+	ft.SetFaults(FaultConfig{DropRate: 1})
+
+	if _, err := ft.SendGetPredecessorRequest(nil, "host1"); err == nil {
+		t.Error("expected dropped request")
+	}
+	if inner.Calls() != 0 {
+		t.Error("dropped request reached inner transport")
+	}
+}
+
+func TestFaultTransportTimeoutAndDuplicate(t *testing.T) {
+	inner := &countingTransport{}
+	ft := NewFaultTransport(inner)
+
+	ft.SetFaults(FaultConfig{TimeoutRate: 1, Timeout: 10 * time.Millisecond})
+	start := time.Now()
+	if _, err := ft.SendGetPredecessorRequest(nil, "host1"); err == nil {
+		t.Error("expected timed out request")
+	}
+	if time.Since(start) < 10*time.Millisecond {
+		t.Error("timed out request returned before timeout")
+	}
+
+	ft.SetFaults(FaultConfig{DuplicateRate: 1})
+	if _, err := ft.SendGetPredecessorRequest(nil, "host1"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(10 * time.Millisecond)
+	if inner.Calls() != 3 {
+		t.Errorf("expected 3 delivered requests, got %d", inner.Calls())
+	}
+}
+
+func TestFaultTransportLatency(t *testing.T) {
+	ft := NewFaultTransport(&countingTransport{})
+	ft.SetFaults(FaultConfig{MinLatency: 20 * time.Millisecond, MaxLatency: 30 * time.Millisecond})
+
+	start := time.Now()
+	if _, err := ft.SendGetPredecessorRequest(nil, "host1"); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("latency not injected, request took %s", elapsed)
+	}
+}
+
+func TestFaultTransportReorder(t *testing.T) {
+	inner := &countingTransport{}
+	ft := NewFaultTransport(inner)
+
+	// the first lookup is held back until the second one to the same host overtook it
+	ft.SetFaults(FaultConfig{ReorderRate: 1, ReorderDelay: 5 * time.Second})
+	start := time.Now()
+	first := make(chan error, 1)
+	go func() {
+		_, err := ft.SendFindSuccessorRequest(nil, NewFindSuccessorRequest([]byte("first"), "host1"))
+		first <- err
+	}()
+	waitFor(t, time.Second, "first lookup to be held back", func() bool {
+		ft.Lock()
+		defer ft.Unlock()
+		return len(ft.held["host1"]) == 1
+	})
+
+	ft.SetFaults(FaultConfig{})
+	if _, err := ft.SendFindSuccessorRequest(nil, NewFindSuccessorRequest([]byte("second"), "host1")); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-first; err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the held lookup to be released by the second one, took %s", elapsed)
+	}
+	inner.Lock()
+	defer inner.Unlock()
+	if len(inner.lookedUp) != 2 || inner.lookedUp[0] != "second" || inner.lookedUp[1] != "first" {
+		t.Errorf("expected the second lookup to be delivered first, got %v", inner.lookedUp)
+	}
+}
+
+func TestFaultTransportReorderDelay(t *testing.T) {
+	inner := &countingTransport{}
+	ft := NewFaultTransport(inner)
+	ft.SetFaults(FaultConfig{ReorderRate: 1, ReorderDelay: 20 * time.Millisecond})
+
+	// without a later request the lookup is only held for ReorderDelay
+	start := time.Now()
+	if _, err := ft.SendFindSuccessorRequest(nil, NewFindSuccessorRequest([]byte("alone"), "host1")); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Errorf("expected the lookup to be held for 20ms, took %s", elapsed)
+	}
+}
+
+func TestFaultTransportPartition(t *testing.T) {
+	ft := NewFaultTransport(&countingTransport{})
+	ft.Partition("split", []string{"a", "b"}, []string{"c"})
+
+	if !ft.Partitioned("a", "c") || !ft.Partitioned("c", "b") {
+		t.Error("hosts in different groups should be partitioned")
+	}
+	if ft.Partitioned("a", "b") || ft.Partitioned("a", "d") {
+		t.Error("hosts in the same group or outside the partition should not be partitioned")
+	}
+
+	ft.Heal("split")
+	if ft.Partitioned("a", "c") {
+		t.Error("partition not healed")
+	}
+}
+
+func TestFaultEndpointsRequireAdminToken(t *testing.T) {
+	admin, router, audit, stop := newTestAdmin(t, "secret")
+	defer stop()
+	ft := NewFaultTransport(&countingTransport{})
+	ft.Install(admin, router)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest("POST", "/fault/partition?name=split&group=a&group=b", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("expected status %d without token, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if ft.Partitioned("a", "b") {
+		t.Error("expected no partition without token")
+	}
+
+	w = httptest.NewRecorder()
+	r := httptest.NewRequest("POST", "/fault/partition?name=split&group=a&group=b", nil)
+	r.Header.Set("Authorization", "Bearer secret")
+	router.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("expected status %d with token, got %d", http.StatusOK, w.Code)
+	}
+	if !ft.Partitioned("a", "b") {
+		t.Error("expected partition with token")
+	}
+
+	entries := decodeAudit(t, audit)
+	if len(entries) != 2 || entries[0].Allowed || !entries[1].Allowed || entries[1].Action != "partition" || entries[1].Target != "split" {
+		t.Errorf("expected rejected and allowed partition in audit log, got %+v", entries)
+	}
+}
+
+// TestFaultTransportPartitionHeal checks that the ring recovers after a partition heals
+func TestFaultTransportPartitionHeal(t *testing.T) {
+	ft := NewFaultTransport(NewTransporter())
+	nodes := newTestNodes(t, 4, ft)
+	defer stopTestNodes(nodes)
+
+	joinTestRing(t, nodes)
+
+	sorted := sortTestNodes(nodes)
+	groupA := []string{sorted[0].server.config.Host, sorted[1].server.config.Host}
+	groupB := []string{sorted[2].server.config.Host, sorted[3].server.config.Host}
+	ft.Partition("split", groupA, groupB)
+	ft.SetFaults(FaultConfig{DropRate: 0.2, MaxLatency: 5 * time.Millisecond})
+
+	// let stabilization run against the partition for a while
+	time.Sleep(10 * DefaultStabilizeInterval)
+	if _, err := sorted[0].server.FindSuccessor(NewFindSuccessorRequest(sorted[3].server.node.ID, "")); err == nil {
+		t.Error("expected lookup across the partition to fail")
+	}
+
+	ft.Reset()
+	waitFor(t, 5*time.Second, "ring to recover", func() bool { return testRingStable(nodes) })
+
+	for _, from := range nodes {
+		for _, owner := range nodes {
+			resp, err := from.server.FindSuccessor(NewFindSuccessorRequest(owner.server.node.ID, ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal([]byte(resp.ID), owner.server.node.ID) {
+				t.Errorf("%s found wrong successor %s of %x", from.server.config.Host, resp.host, owner.server.node.ID)
+			}
+		}
+	}
+}
@@ -15,6 +15,9 @@ import (
 type FindSuccessorRequest struct {
 	ID   string
 	host string
+
+	// Fresh bypasses the lookup caches of the nodes along the path
+	Fresh bool
 }
 
 //FindSuccessorResponse represents a response entry sent back to other server to help find successor
@@ -66,6 +69,11 @@ func (req *FindSuccessorRequest) Decode(r io.Reader) (int, error) {
 	return len(data), nil
 }
 
+// Host returns the host of the successor contained in the response
+func (resp *FindSuccessorResponse) Host() string {
+	return resp.host
+}
+
 // Encode encodes the FindSuccessorResponse into a buffer
 // returns the number of bytes written to the buffer, and error if occurred
 func (resp *FindSuccessorResponse) Encode(buf io.Writer) (int, error) {
@@ -2,6 +2,7 @@ package chord
 
 import (
 	"fmt"
+	"time"
 )
 
 // FingerEntry represents a entry in the finger table
@@ -9,8 +10,34 @@ type FingerEntry struct {
 	start []byte
 	node  []byte
 	host  string
+
+	// candidates are the nodes inside this finger's interval with their measured round trip times,
+	// only kept when proximity neighbor selection is enabled
+	candidates []*FingerCandidate
+}
+
+// FingerCandidate represents a node inside a finger interval that can be used for routing
+type FingerCandidate struct {
+	node []byte
+	host string
+	rtt  time.Duration
 }
 
 func (entry *FingerEntry) String() string {
 	return fmt.Sprintf("start byte: %x \n node byte: %x \n host: %s", entry.start, entry.node, entry.host)
 }
+
+// closestCandidate returns the candidate with the lowest round trip time that lies strictly between id1 and id2,
+// or nil if none of the candidates does
+func (entry *FingerEntry) closestCandidate(id1, id2 []byte) *FingerCandidate {
+	var best *FingerCandidate
+	for _, c := range entry.candidates {
+		if !between(id1, id2, c.node) {
+			continue
+		}
+		if best == nil || c.rtt < best.rtt {
+			best = c
+		}
+	}
+	return best
+}
@@ -0,0 +1,247 @@
+package chord
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	// DefaultReadyStabilizeFailures is the number of stabilizations in a row that may fail before the node is not ready
+	DefaultReadyStabilizeFailures = 3
+
+	// DefaultHealthTimeout is the time the event loop has to answer a health check if not configured
+	DefaultHealthTimeout = time.Second
+)
+
+// HealthStatus represents what the health and readiness checks found on a Chord server
+type HealthStatus struct {
+	Host  string
+	State string
+
+	// Healthy is true while the server runs and its event loop and periodical processes make progress
+	Healthy bool
+
+	// Ready is true while the server is healthy and takes part in a ring
+	Ready bool
+
+	Joined            bool
+	Successor         string `json:",omitempty"`
+	Predecessor       string `json:",omitempty"`
+	LastStabilize     time.Time
+	StabilizeFailures int
+
+	// Problems lists why the server is not healthy or not ready
+	Problems []string `json:",omitempty"`
+}
+
+// healthState tracks the progress of a Chord server that the health and readiness checks report on
+type healthState struct {
+	sync.Mutex
+
+	// joined is true once the node joined a ring, created one, or was joined by another node
+	joined bool
+
+	stabilizeBeat     time.Time
+	fixFingerBeat     time.Time
+	lastStabilize     time.Time
+	stabilizeFailures int
+}
+
+// healthCommand is applied by the event loop to check that it still processes commands
+type healthCommand struct{}
+
+// CommandName returns the name of the command
+func (c *healthCommand) CommandName() string {
+	return "health"
+}
+
+// Apply does nothing, answering at all is the check
+func (c *healthCommand) Apply(s *Server) (interface{}, error) {
+	return nil, nil
+}
+
+// CreateRing marks this node as the first node of a new ring, so that it is ready while it is alone.
+// Other nodes are ready once they joined the ring or another node joined them
+func (server *Server) CreateRing() {
+	server.setJoined(true)
+}
+
+func (server *Server) setJoined(joined bool) {
+	server.health.Lock()
+	server.health.joined = joined
+	server.health.Unlock()
+}
+
+// beat records that a periodical process is still running
+func (server *Server) beat(at *time.Time) {
+	server.health.Lock()
+	*at = time.Now()
+	server.health.Unlock()
+}
+
+// recordStabilize records the outcome of a stabilization
+func (server *Server) recordStabilize(err error) {
+	server.health.Lock()
+	defer server.health.Unlock()
+	if err != nil {
+		server.health.stabilizeFailures++
+		return
+	}
+	server.health.stabilizeFailures = 0
+	server.health.lastStabilize = time.Now()
+}
+
+// Health checks whether the server runs, its event loop answers within HealthTimeout,
+// and its periodical stabilizing and finger fixing ran within twice their max interval
+func (server *Server) Health() *HealthStatus {
+	status := server.healthStatus()
+	state := status.State
+	if state != Running && state != Draining {
+		status.Problems = append(status.Problems, fmt.Sprintf("server is %s", state))
+		return status
+	}
+
+	timeout := durationOrDefault(server.config.HealthTimeout, DefaultHealthTimeout)
+	server.health.Lock()
+	stabilizeBeat, fixFingerBeat := server.health.stabilizeBeat, server.health.fixFingerBeat
+	server.health.Unlock()
+	if _, max := server.stabilizeSchedule.Bounds(); time.Since(stabilizeBeat) > 2*max+timeout {
+		status.Problems = append(status.Problems, fmt.Sprintf("stabilizing did not run since %s", stabilizeBeat.Format(time.RFC3339Nano)))
+	}
+	if _, max := server.fixFingerSchedule.Bounds(); time.Since(fixFingerBeat) > 2*max+timeout {
+		status.Problems = append(status.Problems, fmt.Sprintf("fixing fingers did not run since %s", fixFingerBeat.Format(time.RFC3339Nano)))
+	}
+
+	// a draining server rejects new commands, and its event loop only finishes the queued ones
+	if state == Running {
+		answered := make(chan error, 1)
+		go func() {
+			_, err := server.sendCommand(&healthCommand{})
+			answered <- err
+		}()
+		select {
+		case err := <-answered:
+			if err != nil {
+				status.Problems = append(status.Problems, fmt.Sprintf("event loop failed: %s", err))
+			}
+		case <-time.After(timeout):
+			status.Problems = append(status.Problems, fmt.Sprintf("event loop did not answer within %s", timeout))
+		}
+	}
+
+	status.Healthy = len(status.Problems) == 0
+	return status
+}
+
+// Readiness checks whether the server is healthy and takes part in a ring: it joined a ring,
+// its successor is another node unless it is a single node ring, its predecessor is known,
+// and the recent stabilizations succeeded
+func (server *Server) Readiness() *HealthStatus {
+	status := server.Health()
+	if !status.Healthy {
+		return status
+	}
+
+	if status.State != Running {
+		status.Problems = append(status.Problems, fmt.Sprintf("server is %s", status.State))
+	}
+	if !status.Joined {
+		status.Problems = append(status.Problems, "not joined to a ring")
+	}
+
+	host := server.config.Host
+	if status.Successor == "" {
+		status.Problems = append(status.Problems, "no successor")
+	}
+	if status.Predecessor == "" {
+		status.Problems = append(status.Problems, "no predecessor")
+	}
+	// a node is its own successor only in a single node ring, where it is also its own predecessor
+	if status.Successor == host && status.Predecessor != "" && status.Predecessor != host {
+		status.Problems = append(status.Problems, "successor is itself while predecessor is another node")
+	}
+
+	failures := server.config.ReadyStabilizeFailures
+	if failures <= 0 {
+		failures = DefaultReadyStabilizeFailures
+	}
+	if status.StabilizeFailures >= failures {
+		status.Problems = append(status.Problems, fmt.Sprintf("last %d stabilizations failed", status.StabilizeFailures))
+	}
+	timeout := durationOrDefault(server.config.HealthTimeout, DefaultHealthTimeout)
+	if _, max := server.stabilizeSchedule.Bounds(); time.Since(status.LastStabilize) > 2*max+timeout {
+		status.Problems = append(status.Problems, "no recent successful stabilization")
+	}
+
+	status.Ready = len(status.Problems) == 0
+	return status
+}
+
+// healthStatus collects the state the checks are based on
+func (server *Server) healthStatus() *HealthStatus {
+	status := &HealthStatus{
+		Host:  server.config.Host,
+		State: server.State(),
+	}
+	if succ := server.node.Successor(); succ != nil {
+		status.Successor = succ.host
+	}
+	if pred := server.node.Predecessor(); pred != nil {
+		status.Predecessor = pred.host
+	}
+
+	server.health.Lock()
+	status.Joined = server.health.joined
+	status.LastStabilize = server.health.lastStabilize
+	status.StabilizeFailures = server.health.stabilizeFailures
+	server.health.Unlock()
+	return status
+}
+
+// HealthHandler serves the health and readiness checks of a Chord server for probes such as those of Kubernetes.
+// Like Admin, it is kept apart from the peer protocol, and it needs no authentication since it changes nothing
+type HealthHandler struct {
+	server *Server
+
+	healthzPath string
+	readyzPath  string
+}
+
+// NewHealthHandler initializes the health and readiness checks of server
+func NewHealthHandler(server *Server) *HealthHandler {
+	return &HealthHandler{
+		server:      server,
+		healthzPath: "/healthz",
+		readyzPath:  "/readyz",
+	}
+}
+
+// Install applies the health check routes to an http router
+func (h *HealthHandler) Install(mux *mux.Router) {
+	mux.HandleFunc(h.healthzPath, h.healthzHandler).Methods("GET")
+	mux.HandleFunc(h.readyzPath, h.readyzHandler).Methods("GET")
+}
+
+func (h *HealthHandler) healthzHandler(w http.ResponseWriter, r *http.Request) {
+	status := h.server.Health()
+	writeHealthStatus(w, status, status.Healthy)
+}
+
+func (h *HealthHandler) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	status := h.server.Readiness()
+	writeHealthStatus(w, status, status.Ready)
+}
+
+// writeHealthStatus answers 200 if ok and 503 otherwise, with the status as json
+func writeHealthStatus(w http.ResponseWriter, status *HealthStatus, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
@@ -0,0 +1,106 @@
+package chord
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// getHealth requests path from the health handler of server, and returns the status code and decoded status
+func getHealth(t *testing.T, server *Server, path string) (int, *HealthStatus) {
+	router := mux.NewRouter()
+	NewHealthHandler(server).Install(router)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+
+	status := &HealthStatus{}
+	if err := json.NewDecoder(rec.Body).Decode(status); err != nil {
+		t.Fatal(err)
+	}
+	return rec.Code, status
+}
+
+func TestHealthz(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[0].server
+
+	if code, status := getHealth(t, server, "/healthz"); code != http.StatusOK || !status.Healthy {
+		t.Errorf("expected running server to be healthy, got %d %v", code, status.Problems)
+	}
+
+	server.Stop()
+	if code, status := getHealth(t, server, "/healthz"); code != http.StatusServiceUnavailable || status.Healthy {
+		t.Errorf("expected stopped server to be unhealthy, got %d", code)
+	}
+}
+
+func TestReadyzSingleNodeRing(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[0].server
+
+	code, status := getHealth(t, server, "/readyz")
+	if code != http.StatusServiceUnavailable || status.Ready {
+		t.Errorf("expected node that did not join a ring to be not ready")
+	}
+
+	server.CreateRing()
+	waitFor(t, 5*time.Second, "single node ring to be ready", func() bool {
+		code, _ := getHealth(t, server, "/readyz")
+		return code == http.StatusOK
+	})
+}
+
+func TestReadyzRing(t *testing.T) {
+	nodes := newTestNodes(t, 3, nil)
+	defer stopTestNodes(nodes)
+	joinTestNodes(t, nodes)
+
+	// the first node never joins, it becomes part of the ring when the others join it
+	waitFor(t, 10*time.Second, "all nodes to be ready", func() bool {
+		for _, node := range nodes {
+			if !node.server.Readiness().Ready {
+				return false
+			}
+		}
+		return true
+	})
+
+	// a node whose successor is gone fails to stabilize, and is not ready until the ring is repaired
+	nodes[1].server.Stop()
+	nodes[1].http.Close()
+	waitFor(t, 5*time.Second, "a node to notice its successor is gone", func() bool {
+		for _, node := range []*testNode{nodes[0], nodes[2]} {
+			if !node.server.Readiness().Ready {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+func TestReadyzDraining(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[0].server
+	server.CreateRing()
+	waitFor(t, 5*time.Second, "single node ring to be ready", func() bool {
+		return server.Readiness().Ready
+	})
+
+	server.Lock()
+	server.state = Draining
+	server.Unlock()
+	if status := server.Readiness(); status.Ready {
+		t.Errorf("expected draining server to be not ready")
+	}
+	if status := server.Health(); !status.Healthy {
+		t.Errorf("expected draining server to be healthy, got %v", status.Problems)
+	}
+	server.SetState(Running)
+}
@@ -0,0 +1,183 @@
+package chord
+
+import (
+	"bytes"
+	"fmt"
+	"net/http/httptest"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// testNode is a Chord server serving its peer protocol on an in-process http server
+type testNode struct {
+	server *Server
+	http   *httptest.Server
+}
+
+// newTestNodes starts n Chord servers with distinct IDs on in-process http servers,
+// every server sends its requests through transport if given
+func newTestNodes(t *testing.T, n int, transport Transport) []*testNode {
+	nodes := []*testNode{}
+	ids := map[string]bool{}
+	for len(nodes) < n {
+		router := mux.NewRouter()
+		ts := httptest.NewUnstartedServer(router)
+		config := DefaultConfig("http://" + ts.Listener.Addr().String())
+		config.HashBits = 8
+		config.NumNodes = 256
+
+		httpTransporter := NewTransporter()
+		var tr Transport = httpTransporter
+		if transport != nil {
+			tr = transport
+		}
+		server := NewServer(config.Host, config, tr)
+		if ids[string(server.node.ID)] {
+			ts.Close()
+			continue
+		}
+		ids[string(server.node.ID)] = true
+
+		httpTransporter.Install(server, router)
+		ts.Start()
+		if err := server.Start(); err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, &testNode{server: server, http: ts})
+	}
+	return nodes
+}
+
+// stopTestNodes stops all Chord servers and their http servers
+func stopTestNodes(nodes []*testNode) {
+	for _, node := range nodes {
+		if node.server.Running() {
+			node.server.Stop()
+		}
+		node.http.Close()
+	}
+}
+
+// joinTestNodes joins all nodes into the ring of the first node
+func joinTestNodes(t *testing.T, nodes []*testNode) {
+	for _, node := range nodes[1:] {
+		if err := node.server.Join(nodes[0].server.config.Host); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// sortTestNodes returns the nodes sorted by ID, which is the expected order of the ring
+func sortTestNodes(nodes []*testNode) []*testNode {
+	sorted := append([]*testNode{}, nodes...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].server.node.ID, sorted[j].server.node.ID) < 0
+	})
+	return sorted
+}
+
+// testRingStable checks whether every node's successor and predecessor are its neighbours in ID order
+func testRingStable(nodes []*testNode) bool {
+	sorted := sortTestNodes(nodes)
+	for i, node := range sorted {
+		succ := node.server.node.Successor()
+		pred := node.server.node.Predecessor()
+		next := sorted[(i+1)%len(sorted)].server
+		prev := sorted[(i+len(sorted)-1)%len(sorted)].server
+		if succ == nil || succ.host != next.config.Host {
+			return false
+		}
+		if pred == nil || pred.host != prev.config.Host {
+			return false
+		}
+	}
+	return true
+}
+
+// newTestRing starts n Chord servers like newTestNodes, joins them into one ring, and waits until it is stable
+func newTestRing(t *testing.T, n int, transport Transport) []*testNode {
+	nodes := newTestNodes(t, n, transport)
+	joinTestRing(t, nodes)
+	return nodes
+}
+
+// joinTestRing joins all nodes into the ring of the first node, and waits until the ring is stable
+func joinTestRing(t *testing.T, nodes []*testNode) {
+	joinTestNodes(t, nodes)
+	waitFor(t, 10*time.Second, "ring to stabilize", func() bool {
+		return testRingStable(nodes)
+	})
+}
+
+// waitFor polls cond until it holds, or fails the test after timeout
+func waitFor(t *testing.T, timeout time.Duration, what string, cond func() bool) {
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(DefaultStabilizeInterval)
+	}
+}
+
+// errNotSupported is returned by nopTransport for every request
+var errNotSupported = fmt.Errorf("not supported")
+
+// nopTransport is a Transport failing every request. Test transports embed it and implement
+// only the requests they simulate, so that adding a request to Transport does not touch them
+type nopTransport struct{}
+
+func (nopTransport) SendFindSuccessorRequest(server *Server, req *FindSuccessorRequest) (*FindSuccessorResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendNotifyRequest(server *Server, req *NotifyRequest) (*NotifyResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendGetPredecessorRequest(server *Server, host string) (*GetPredecessorResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendGetSuccessorRequest(server *Server, host string) (*FindSuccessorResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendBatchFindSuccessorRequest(server *Server, req *BatchFindSuccessorRequest) (*BatchFindSuccessorResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendPingRequest(server *Server, host string, challenge []byte) (*PingResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendLeaveRequest(server *Server, req *LeaveRequest) error {
+	return errNotSupported
+}
+
+func (nopTransport) SendDoRequest(server *Server, req *DoRequest) (*DoResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendRouteRequest(server *Server, req *RouteRequest) (*RouteResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendBroadcastRequest(server *Server, req *BroadcastRequest) (*BroadcastResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendSizeRequest(server *Server, host string, share float64) (*SizeResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendLoadRequest(server *Server, host string) (*LoadResponse, error) {
+	return nil, errNotSupported
+}
+
+func (nopTransport) SendTransferRequest(server *Server, req *TransferRequest) (*TransferResponse, error) {
+	return nil, errNotSupported
+}
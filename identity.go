@@ -0,0 +1,265 @@
+package chord
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"os"
+	"sync"
+)
+
+// ErrIdentityMismatch is returned when the ID a node claims is not the ID it is allowed to use on its host
+var ErrIdentityMismatch = errors.New("claimed ID does not match host")
+
+// ErrIdentityProof is returned when a node answers a ping with a public key that does not prove its claimed ID
+var ErrIdentityProof = errors.New("invalid identity proof")
+
+// IdentityVerifier checks whether a node claiming id is allowed to use it on host
+type IdentityVerifier func(id []byte, host string) error
+
+// hashLock serializes the use of the configured hash function, which keeps state between writes
+var hashLock sync.Mutex
+
+// hashHost hashes host into the ID space of config
+func hashHost(config *Config, host string) []byte {
+	return hashBytes(config, []byte(host))
+}
+
+// hashBytes hashes data into the ID space of config
+func hashBytes(config *Config, data []byte) []byte {
+	hashLock.Lock()
+	hash := config.HashFunc
+	hash.Reset()
+	hash.Write(data)
+	b := hash.Sum(nil)
+	hash.Reset()
+	hashLock.Unlock()
+
+	idInt := big.Int{}
+	idInt.SetBytes(b)
+
+	// Get the ceiling
+	two := big.NewInt(2)
+	ceil := big.Int{}
+	ceil.Exp(two, big.NewInt(int64(config.HashBits)), nil)
+
+	// Apply the mod
+	idInt.Mod(&idInt, &ceil)
+
+	return idInt.Bytes()
+}
+
+// LoadIdentity resolves the stable identity of the node. With IdentityKeyPath set, the ed25519 private key is read
+// from that file, or generated and saved there on first use, and the ID is the hash of its public key.
+// With ID set, the hex encoded ID is checked. Without either, the ID is the hash of the host
+func (config *Config) LoadIdentity() error {
+	if config.IdentityKeyPath != "" {
+		key, err := loadOrCreateKey(config.IdentityKeyPath)
+		if err != nil {
+			return fmt.Errorf("load identity failed: %s", err)
+		}
+		id := hex.EncodeToString(hashBytes(config, key.Public().(ed25519.PublicKey)))
+		if config.ID != "" && config.ID != id {
+			return fmt.Errorf("load identity failed: ID %s is not the hash %s of the identity key", config.ID, id)
+		}
+		config.ID = id
+		config.identityKey = key
+	}
+
+	if config.ID == "" {
+		return nil
+	}
+	id, err := hex.DecodeString(config.ID)
+	if err != nil {
+		return fmt.Errorf("load identity failed: %s", err)
+	}
+	if new(big.Int).SetBytes(id).BitLen() > config.HashBits {
+		return fmt.Errorf("load identity failed: ID %s out of the %d bit ID space", config.ID, config.HashBits)
+	}
+	return nil
+}
+
+// stableID returns the configured stable ID of the node, or nil if the ID is derived from the host.
+// It returns the error of loading the identity, a node must not fall back to the hash of its host
+// and join the ring at another place than its configured identity
+func (config *Config) stableID() ([]byte, error) {
+	if config.ID == "" && config.IdentityKeyPath == "" {
+		return nil, nil
+	}
+	if err := config.LoadIdentity(); err != nil {
+		return nil, err
+	}
+	id, _ := hex.DecodeString(config.ID)
+	// IDs are compared as big-endian numbers without leading zeros, like the hashed IDs
+	return new(big.Int).SetBytes(id).Bytes(), nil
+}
+
+// loadOrCreateKey reads the PEM encoded ed25519 private key at path, generating and saving one if the file does not exist
+func loadOrCreateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, err
+		}
+		data = pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		if err = ioutil.WriteFile(path, data, 0600); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data in %s", path)
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s does not hold an ed25519 key", path)
+	}
+	return key, nil
+}
+
+// pingProof returns the message a node with a key identity signs to answer a ping
+func pingProof(challenge []byte, id []byte, host string) []byte {
+	return []byte(fmt.Sprintf("chord-ping\n%x\n%x\n%s", challenge, id, host))
+}
+
+// HashIdentityVerifier returns a verifier accepting only IDs equal to the configured hash of the host,
+// which is how NewNode generates IDs
+func HashIdentityVerifier(config *Config) IdentityVerifier {
+	return func(id []byte, host string) error {
+		if !bytes.Equal(hashHost(config, host), id) {
+			return fmt.Errorf("%x on %s: %s", id, host, ErrIdentityMismatch)
+		}
+		return nil
+	}
+}
+
+// identityVerifier returns the verifier of claimed IDs, or nil if claimed IDs are trusted
+func (server *Server) identityVerifier() IdentityVerifier {
+	if server.config.IdentityVerifier != nil {
+		return server.config.IdentityVerifier
+	}
+	if server.config.VerifyIdentity {
+		return HashIdentityVerifier(server.config)
+	}
+	return nil
+}
+
+// verifyPeer checks the identity a node claims before it is accepted as predecessor or successor.
+// The claimed ID is checked against the identity scheme, and when probing is enabled,
+// the claimed host is pinged once to check that it answers with the claimed ID
+func (server *Server) verifyPeer(id []byte, host string) error {
+	if host == server.config.Host {
+		return nil
+	}
+
+	if verifier := server.identityVerifier(); verifier != nil {
+		if err := verifier(id, host); err != nil {
+			return fmt.Errorf("Chord verify peer failed: %s", err)
+		}
+	}
+
+	if !server.config.ProbeHosts {
+		return nil
+	}
+	server.Lock()
+	probedID, probed := server.probedHosts[host]
+	server.Unlock()
+	if probed && probedID == string(id) {
+		return nil
+	}
+
+	challenge := make([]byte, 16)
+	if _, err := rand.Read(challenge); err != nil {
+		return fmt.Errorf("Chord verify peer failed: %s", err)
+	}
+	pingResp, err := server.transporter.SendPingRequest(server, host, challenge)
+	if err != nil {
+		return fmt.Errorf("Chord verify peer failed: %s", err)
+	}
+	if !bytes.Equal(pingResp.ID, id) || pingResp.host != host {
+		return fmt.Errorf("Chord verify peer failed: %x on %s answered as %x on %s: %s", id, host, pingResp.ID, pingResp.host, ErrIdentityMismatch)
+	}
+	// a node with a key identity proves that it holds the key its ID is the hash of
+	if len(pingResp.PublicKey) > 0 {
+		if len(pingResp.PublicKey) != ed25519.PublicKeySize ||
+			!bytes.Equal(hashBytes(server.config, pingResp.PublicKey), id) ||
+			!ed25519.Verify(pingResp.PublicKey, pingProof(challenge, id, host), pingResp.Signature) {
+			return fmt.Errorf("Chord verify peer failed: %x on %s: %s", id, host, ErrIdentityProof)
+		}
+	}
+
+	server.Lock()
+	server.probedHosts[host] = string(id)
+	server.Unlock()
+	return nil
+}
+
+// processPingRequest answers a ping with the identity of this node, signing the challenge if it has a key identity
+func (server *Server) processPingRequest(challenge []byte) *PingResponse {
+	resp := NewPingResponse(server.node.GetID(), server.config.Host)
+	if key := server.config.identityKey; key != nil {
+		resp.PublicKey = key.Public().(ed25519.PublicKey)
+		resp.Signature = ed25519.Sign(key, pingProof(challenge, server.node.GetID(), server.config.Host))
+	}
+	return resp
+}
+
+// updateAddress points every reference to the node with id at host, when that node is known under another host.
+// Nodes with a stable identity keep their ID when their host changes, and the ring learns the new host
+// from the notify and stabilize requests that carry it. It returns whether any reference was updated
+func (server *Server) updateAddress(id []byte, host string) bool {
+	if host == "" || bytes.Equal(id, server.node.GetID()) {
+		return false
+	}
+
+	updated := false
+	node := server.node
+	if succ := node.Successor(); succ != nil && bytes.Equal(succ.ID, id) && succ.host != host {
+		node.SetSuccessor(NewRemoteNode(id, host))
+		updated = true
+	}
+	if pred := node.Predecessor(); pred != nil && bytes.Equal(pred.ID, id) && pred.host != host {
+		node.SetPredecessor(NewRemoteNode(id, host))
+		updated = true
+	}
+	node.Lock()
+	for _, entry := range node.finger {
+		if entry != nil && bytes.Equal(entry.node, id) && entry.host != host {
+			entry.host = host
+			updated = true
+		}
+	}
+	node.Unlock()
+
+	if updated {
+		log.Printf("[Identity]host %s learned that %x moved to %s", server.config.Host, id, host)
+		if server.lookupCache != nil {
+			server.lookupCache.Invalidate(NewRemoteNode(id, host))
+		}
+		server.AddPeers(host)
+		server.resetSchedules()
+	}
+	return updated
+}
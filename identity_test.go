@@ -0,0 +1,249 @@
+package chord
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestHashIdentityVerifier(t *testing.T) {
+	config := DefaultConfig("http://localhost:3000")
+	verifier := HashIdentityVerifier(config)
+
+	id, err := generateID(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verifier(id, config.Host); err != nil {
+		t.Errorf("expected generated ID to verify, got %s", err)
+	}
+	if !bytes.Equal(id, hashHelper(config.Host)) {
+		t.Errorf("expected generated ID to stay the hash of the host")
+	}
+	if err := verifier(hashHelper("http://localhost:4000"), config.Host); err == nil {
+		t.Errorf("expected ID of another host to be rejected")
+	}
+}
+
+func TestNotifyRejectsForgedID(t *testing.T) {
+	nodes := newTestNodes(t, 2, nil)
+	defer stopTestNodes(nodes)
+	target, forger := nodes[0].server, nodes[1].server
+	target.config.VerifyIdentity = true
+
+	forged := []byte{target.node.ID[0] - 1}
+	if _, err := target.notify(NewNotifyRequest(forged, forger.config.Host, target.config.Host)); err == nil {
+		t.Errorf("expected forged ID to be rejected")
+	}
+	if target.node.Predecessor() != nil {
+		t.Errorf("expected predecessor to stay unset, got %s", target.node.Predecessor().host)
+	}
+
+	if _, err := target.notify(NewNotifyRequest(forger.node.ID, forger.config.Host, target.config.Host)); err != nil {
+		t.Errorf("expected hashed ID to be accepted, got %s", err)
+	}
+}
+
+func TestCustomIdentityVerifier(t *testing.T) {
+	nodes := newTestNodes(t, 2, nil)
+	defer stopTestNodes(nodes)
+	target, peer := nodes[0].server, nodes[1].server
+	target.config.IdentityVerifier = func(id []byte, host string) error {
+		return fmt.Errorf("%s is not trusted", host)
+	}
+
+	if _, err := target.notify(NewNotifyRequest(peer.node.ID, peer.config.Host, target.config.Host)); err == nil {
+		t.Errorf("expected custom verifier to reject peer")
+	}
+}
+
+func TestProbeHosts(t *testing.T) {
+	nodes := newTestNodes(t, 3, nil)
+	defer stopTestNodes(nodes)
+	for _, node := range nodes {
+		node.server.config.ProbeHosts = true
+	}
+	target, peer := nodes[0].server, nodes[1].server
+
+	// the claimed host is alive but answers with another ID
+	forged := nodes[2].server.node.ID
+	if err := target.verifyPeer(forged, peer.config.Host); err == nil {
+		t.Errorf("expected probe to reject ID not owned by %s", peer.config.Host)
+	}
+	if err := target.verifyPeer(peer.node.ID, peer.config.Host); err != nil {
+		t.Errorf("expected probe to accept %s, got %s", peer.config.Host, err)
+	}
+	if err := target.verifyPeer(peer.node.ID, "http://127.0.0.1:1"); err == nil {
+		t.Errorf("expected probe of unreachable host to fail")
+	}
+
+	joinTestRing(t, nodes)
+}
+
+func TestLoadIdentityKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	keyPath := filepath.Join(dir, "identity.pem")
+	config := DefaultConfig("http://localhost:3000")
+	config.HashBits = 8
+	config.IdentityKeyPath = keyPath
+	first, err := generateID(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = os.Stat(keyPath); err != nil {
+		t.Fatalf("expected identity key to be generated, got %s", err)
+	}
+
+	// the same key on another host keeps the ID
+	moved := DefaultConfig("http://localhost:4000")
+	moved.HashBits = 8
+	moved.IdentityKeyPath = keyPath
+	if second, err := generateID(moved); err != nil || !bytes.Equal(first, second) {
+		t.Errorf("expected ID %x to survive the host change, got %x", first, second)
+	}
+
+	// any ID other than the key hash, which is random
+	other := "01"
+	if bytes.Equal(first, []byte{1}) {
+		other = "02"
+	}
+	moved.ID = other
+	if err = moved.LoadIdentity(); err == nil && moved.ID == other {
+		t.Errorf("expected an ID other than the key hash to be rejected")
+	}
+}
+
+func TestLoadIdentityExplicitID(t *testing.T) {
+	config := DefaultConfig("http://localhost:3000")
+	config.HashBits = 8
+	config.ID = "2a"
+	if id, err := generateID(config); err != nil || !bytes.Equal(id, []byte{42}) {
+		t.Errorf("expected explicit ID 2a, got %x, %v", id, err)
+	}
+
+	for _, id := range []string{"zz", "0100"} {
+		config.ID = id
+		if err := config.LoadIdentity(); err == nil {
+			t.Errorf("expected invalid ID %s to be rejected", id)
+		}
+	}
+}
+
+func TestInvalidIdentityFailsStart(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	badKey := filepath.Join(dir, "identity.pem")
+	if err = ioutil.WriteFile(badKey, []byte("not a key"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	configs := []func(config *Config){
+		func(config *Config) { config.ID = "zz" },
+		func(config *Config) { config.ID = "0100" },
+		func(config *Config) { config.IdentityKeyPath = badKey },
+	}
+	for _, configure := range configs {
+		config := DefaultConfig("http://localhost:3000")
+		config.HashBits = 8
+		configure(config)
+		server := NewServer(config.Host, config, NewTransporter())
+		if err := server.Start(); err == nil {
+			server.Stop()
+			t.Errorf("expected start with ID %q and key %q to fail", config.ID, config.IdentityKeyPath)
+		}
+		if err := server.Join("http://localhost:4000"); err == nil || !strings.Contains(err.Error(), "identity") {
+			t.Errorf("expected join with ID %q and key %q to fail on the identity, got %v", config.ID, config.IdentityKeyPath, err)
+		}
+	}
+}
+
+func TestProbeVerifiesKeyProof(t *testing.T) {
+	nodes := newTestNodes(t, 2, nil)
+	defer stopTestNodes(nodes)
+	target, peer := nodes[0].server, nodes[1].server
+	target.config.ProbeHosts = true
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	peer.config.identityKey = key
+	id := hashBytes(peer.config, key.Public().(ed25519.PublicKey))
+	if err = target.verifyPeer(id, peer.config.Host); err == nil {
+		t.Errorf("expected probe to reject an ID the peer does not answer with")
+	}
+
+	peer.node.ID = id
+	if err = target.verifyPeer(id, peer.config.Host); err != nil {
+		t.Errorf("expected key proof to verify, got %s", err)
+	}
+
+	// a key whose hash is not the ID does not prove it
+	_, other, _ := ed25519.GenerateKey(rand.Reader)
+	peer.config.identityKey = other
+	target.probedHosts = make(map[string]string)
+	if err = target.verifyPeer(id, peer.config.Host); err == nil {
+		t.Errorf("expected probe to reject a key that does not hash to the ID")
+	}
+}
+
+func TestRingLearnsMovedNode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	nodes := newTestNodes(t, 3, nil)
+	defer func() { stopTestNodes(nodes) }()
+	old := nodes[0].server
+	old.config.StatePath = filepath.Join(dir, "state.json")
+	joinTestRing(t, nodes)
+	if err = old.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	nodes[0].http.Close()
+
+	// the node restarts on another address with its ID configured explicitly
+	router := mux.NewRouter()
+	ts := httptest.NewUnstartedServer(router)
+	config := DefaultConfig("http://" + ts.Listener.Addr().String())
+	config.HashBits = 8
+	config.NumNodes = 256
+	config.ID = hex.EncodeToString(old.node.ID)
+	config.StatePath = old.config.StatePath
+	transporter := NewTransporter()
+	moved := NewServer(config.Host, config, transporter)
+	transporter.Install(moved, router)
+	ts.Start()
+	nodes[0] = &testNode{server: moved, http: ts}
+
+	if !bytes.Equal(moved.node.ID, old.node.ID) {
+		t.Fatalf("expected moved node to keep ID %x, got %x", old.node.ID, moved.node.ID)
+	}
+	if err = moved.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, 10*time.Second, "ring to learn the new address", func() bool {
+		return testRingStable(nodes)
+	})
+}
@@ -0,0 +1,266 @@
+package chord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NodeSnapshot represents a point-in-time copy of a node's routing state
+type NodeSnapshot struct {
+	ID          []byte
+	Host        string
+	Successor   *SnapshotEntry
+	Predecessor *SnapshotEntry
+	Finger      []*SnapshotEntry
+}
+
+// SnapshotEntry represents a remote node referenced by a NodeSnapshot
+type SnapshotEntry struct {
+	ID   []byte
+	Host string
+}
+
+// Violation represents a single broken ring invariant
+type Violation struct {
+	Host     string
+	Check    string
+	Expected string
+	Actual   string
+}
+
+// RingReport represents the result of checking the ring invariants over a set of snapshots
+type RingReport struct {
+	Nodes      int
+	Violations []*Violation
+}
+
+// Snapshot returns a copy of this server's routing state
+func (server *Server) Snapshot() *NodeSnapshot {
+	node := server.node
+	snapshot := &NodeSnapshot{
+		ID:          node.GetID(),
+		Host:        server.config.Host,
+		Successor:   newSnapshotEntry(node.Successor()),
+		Predecessor: newSnapshotEntry(node.Predecessor()),
+	}
+	for _, entry := range node.Finger() {
+		if entry == nil || entry.host == "" {
+			snapshot.Finger = append(snapshot.Finger, nil)
+			continue
+		}
+		snapshot.Finger = append(snapshot.Finger, &SnapshotEntry{ID: entry.node, Host: entry.host})
+	}
+	return snapshot
+}
+
+func newSnapshotEntry(remote *RemoteNode) *SnapshotEntry {
+	if remote == nil {
+		return nil
+	}
+	return &SnapshotEntry{ID: remote.ID, Host: remote.host}
+}
+
+func (entry *SnapshotEntry) String() string {
+	if entry == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("%s(%x)", entry.Host, entry.ID)
+}
+
+// -------------------------------------------------------------------------
+//
+// Checking invariants
+//
+// -------------------------------------------------------------------------
+
+// CheckRing checks the Chord invariants over the snapshots of all nodes in a ring:
+// - every node's successor is the next node by ID
+// - every successor's predecessor points back
+// - following successors forms exactly one cycle through all nodes
+// - each finger[i] is the true successor of powerOffset(id, i, hashBits)
+func CheckRing(snapshots []*NodeSnapshot, hashBits int) *RingReport {
+	report := &RingReport{Nodes: len(snapshots)}
+	if len(snapshots) == 0 {
+		return report
+	}
+
+	sorted := append([]*NodeSnapshot{}, snapshots...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].ID, sorted[j].ID) < 0
+	})
+
+	byHost := make(map[string]*NodeSnapshot)
+	for i, snapshot := range sorted {
+		byHost[snapshot.Host] = snapshot
+		if i > 0 && bytes.Equal(sorted[i-1].ID, snapshot.ID) {
+			report.add(snapshot.Host, "unique id", "unique", fmt.Sprintf("%x shared with %s", snapshot.ID, sorted[i-1].Host))
+		}
+	}
+
+	for i, snapshot := range sorted {
+		next := sorted[(i+1)%len(sorted)]
+		if snapshot.Successor == nil || snapshot.Successor.Host != next.Host {
+			report.add(snapshot.Host, "successor", fmt.Sprintf("%s(%x)", next.Host, next.ID), snapshot.Successor.String())
+		}
+
+		if snapshot.Successor != nil {
+			if succ, ok := byHost[snapshot.Successor.Host]; ok {
+				if succ.Predecessor == nil || succ.Predecessor.Host != snapshot.Host {
+					report.add(succ.Host, "predecessor", fmt.Sprintf("%s(%x)", snapshot.Host, snapshot.ID), succ.Predecessor.String())
+				}
+			}
+		}
+
+		for j := 0; j < hashBits; j++ {
+			start := powerOffset(snapshot.ID, j, hashBits)
+			owner := trueSuccessor(sorted, start)
+			var actual *SnapshotEntry
+			if j < len(snapshot.Finger) {
+				actual = snapshot.Finger[j]
+			}
+			if actual == nil || actual.Host != owner.Host {
+				report.add(snapshot.Host, fmt.Sprintf("finger[%d] start %x", j, start), fmt.Sprintf("%s(%x)", owner.Host, owner.ID), actual.String())
+			}
+		}
+	}
+
+	report.checkCycle(sorted, byHost)
+	return report
+}
+
+// checkCycle follows successor pointers and checks that they form exactly one cycle through all nodes
+func (report *RingReport) checkCycle(sorted []*NodeSnapshot, byHost map[string]*NodeSnapshot) {
+	const (
+		unvisited = iota
+		walking
+		done
+	)
+
+	state := make(map[string]int)
+	cycles := 0
+	for _, start := range sorted {
+		walk := []*NodeSnapshot{}
+		current := start
+		for current != nil && state[current.Host] == unvisited {
+			state[current.Host] = walking
+			walk = append(walk, current)
+			current = successorSnapshot(current, byHost)
+		}
+
+		// the walk ran into itself, so it found a new cycle
+		if current != nil && state[current.Host] == walking {
+			cycles++
+			for i, snapshot := range walk {
+				if snapshot == current && len(walk)-i != len(sorted) {
+					report.add(current.Host, "cycle", fmt.Sprintf("cycle through %d nodes", len(sorted)), fmt.Sprintf("cycle through %d nodes", len(walk)-i))
+				}
+			}
+		}
+		for _, snapshot := range walk {
+			state[snapshot.Host] = done
+		}
+	}
+
+	if cycles != 1 {
+		report.add("", "cycle", "exactly 1 cycle", fmt.Sprintf("%d cycles", cycles))
+	}
+}
+
+// successorSnapshot returns the snapshot of the successor of snapshot, or nil if it is not part of the snapshots
+func successorSnapshot(snapshot *NodeSnapshot, byHost map[string]*NodeSnapshot) *NodeSnapshot {
+	if snapshot.Successor == nil {
+		return nil
+	}
+	return byHost[snapshot.Successor.Host]
+}
+
+// trueSuccessor returns the first node whose ID is equal to or follows id, sorted must be sorted by ID
+func trueSuccessor(sorted []*NodeSnapshot, id []byte) *NodeSnapshot {
+	for _, snapshot := range sorted {
+		if bytes.Compare(snapshot.ID, id) >= 0 {
+			return snapshot
+		}
+	}
+	return sorted[0]
+}
+
+func (report *RingReport) add(host string, check string, expected string, actual string) {
+	report.Violations = append(report.Violations, &Violation{
+		Host:     host,
+		Check:    check,
+		Expected: expected,
+		Actual:   actual,
+	})
+}
+
+// OK checks whether no invariant is broken
+func (report *RingReport) OK() bool {
+	return len(report.Violations) == 0
+}
+
+// Err returns an error describing all broken invariants, or nil if the ring is consistent
+func (report *RingReport) Err() error {
+	if report.OK() {
+		return nil
+	}
+	return fmt.Errorf("ring check failed:\n%s", report)
+}
+
+// String formats the report as a diff of the expected and actual ring state
+func (report *RingReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d nodes, %d violations\n", report.Nodes, len(report.Violations))
+	for _, v := range report.Violations {
+		fmt.Fprintf(&b, "%s %s\n", v.Host, v.Check)
+		fmt.Fprintf(&b, "- %s\n", v.Expected)
+		fmt.Fprintf(&b, "+ %s\n", v.Actual)
+	}
+	return b.String()
+}
+
+// -------------------------------------------------------------------------
+//
+// Live ring
+//
+// -------------------------------------------------------------------------
+
+// CheckLiveRing fetches the snapshots of the given hosts through their debug endpoints and checks the ring invariants,
+// unreachable hosts are reported as violations
+func CheckLiveRing(t *Transporter, hosts []string, hashBits int) *RingReport {
+	snapshots := []*NodeSnapshot{}
+	unreachable := []*Violation{}
+	for _, host := range hosts {
+		snapshot, err := t.GetSnapshot(host)
+		if err != nil {
+			unreachable = append(unreachable, &Violation{Host: host, Check: "reachable", Expected: "snapshot", Actual: err.Error()})
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+
+	report := CheckRing(snapshots, hashBits)
+	report.Nodes = len(hosts)
+	report.Violations = append(unreachable, report.Violations...)
+	return report
+}
+
+// encodeSnapshot encodes a NodeSnapshot as json
+func encodeSnapshot(snapshot *NodeSnapshot) ([]byte, error) {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return nil, fmt.Errorf("encode NodeSnapshot failed: %s", err)
+	}
+	return data, nil
+}
+
+// decodeSnapshot decodes a json encoded NodeSnapshot
+func decodeSnapshot(data []byte) (*NodeSnapshot, error) {
+	snapshot := &NodeSnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return nil, fmt.Errorf("decode NodeSnapshot failed: %s", err)
+	}
+	return snapshot, nil
+}
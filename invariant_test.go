@@ -0,0 +1,90 @@
+package chord
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// consistentSnapshots builds the snapshots of a consistent ring of nodes with given IDs, sorted ascending
+func consistentSnapshots(ids []byte, hashBits int) []*NodeSnapshot {
+	snapshots := []*NodeSnapshot{}
+	for _, id := range ids {
+		snapshots = append(snapshots, &NodeSnapshot{ID: []byte{id}, Host: fmt.Sprintf("host%d", id)})
+	}
+	for i, snapshot := range snapshots {
+		next := snapshots[(i+1)%len(snapshots)]
+		prev := snapshots[(i+len(snapshots)-1)%len(snapshots)]
+		snapshot.Successor = &SnapshotEntry{ID: next.ID, Host: next.Host}
+		snapshot.Predecessor = &SnapshotEntry{ID: prev.ID, Host: prev.Host}
+		for j := 0; j < hashBits; j++ {
+			owner := trueSuccessor(snapshots, powerOffset(snapshot.ID, j, hashBits))
+			snapshot.Finger = append(snapshot.Finger, &SnapshotEntry{ID: owner.ID, Host: owner.Host})
+		}
+	}
+	return snapshots
+}
+
+func TestCheckRingConsistent(t *testing.T) {
+	report := CheckRing(consistentSnapshots([]byte{1, 3, 6}, 3), 3)
+	if err := report.Err(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCheckRingWrongSuccessor(t *testing.T) {
+	snapshots := consistentSnapshots([]byte{1, 3, 6}, 3)
+	snapshots[0].Successor = &SnapshotEntry{ID: []byte{6}, Host: "host6"}
+
+	report := CheckRing(snapshots, 3)
+	if report.OK() {
+		t.Fatal("expected violations")
+	}
+	if !strings.Contains(report.String(), "host1 successor\n- host3(03)\n+ host6(06)") {
+		t.Errorf("missing successor diff in report:\n%s", report)
+	}
+}
+
+func TestCheckRingTwoCycles(t *testing.T) {
+	// 1 -> 3 -> 1 and 5 -> 6 -> 5
+	snapshots := consistentSnapshots([]byte{1, 3, 5, 6}, 3)
+	snapshots[1].Successor = &SnapshotEntry{ID: []byte{1}, Host: "host1"}
+	snapshots[3].Successor = &SnapshotEntry{ID: []byte{5}, Host: "host5"}
+
+	report := CheckRing(snapshots, 3)
+	if !strings.Contains(report.String(), "+ 2 cycles") {
+		t.Errorf("expected 2 cycles in report:\n%s", report)
+	}
+}
+
+func TestCheckRingWrongFinger(t *testing.T) {
+	snapshots := consistentSnapshots([]byte{1, 3, 6}, 3)
+	snapshots[2].Finger[2] = nil
+
+	report := CheckRing(snapshots, 3)
+	if len(report.Violations) != 1 || report.Violations[0].Check != "finger[2] start 02" {
+		t.Errorf("expected a single finger violation:\n%s", report)
+	}
+}
+
+func TestCheckLiveRing(t *testing.T) {
+	nodes := newTestNodes(t, 3, nil)
+	defer stopTestNodes(nodes)
+	joinTestNodes(t, nodes)
+
+	hosts := []string{}
+	for _, node := range nodes {
+		hosts = append(hosts, node.server.config.Host)
+	}
+
+	transporter := NewTransporter()
+	waitFor(t, 10*time.Second, "live ring to pass the invariant check", func() bool {
+		return CheckLiveRing(transporter, hosts, 8).OK()
+	})
+
+	report := CheckLiveRing(transporter, append(hosts, "http://127.0.0.1:1"), 8)
+	if report.OK() || report.Violations[0].Check != "reachable" {
+		t.Errorf("expected unreachable host violation:\n%s", report)
+	}
+}
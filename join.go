@@ -0,0 +1,148 @@
+package chord
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// DefaultJoinTimeout is the time JoinAny keeps retrying the seeds if not configured
+	DefaultJoinTimeout = 30 * time.Second
+
+	// DefaultJoinBackoff is the wait after the first round of seeds failed if not configured
+	DefaultJoinBackoff = 100 * time.Millisecond
+
+	// DefaultMaxJoinBackoff is the max wait between two rounds of seeds if not configured
+	DefaultMaxJoinBackoff = 5 * time.Second
+)
+
+// ErrNoSeeds is returned when a node is asked to join through seeds but has none
+var ErrNoSeeds = errors.New("no seed hosts")
+
+// JoinAny joins an existing chord ring through any of the seeds. Seeds are tried in random order,
+// and rounds of seeds are retried with jittered exponential backoff until the join timeout passes,
+// so that nodes started at the same time do not have to be ordered.
+//
+// Nodes joining each other while all of them are alone could form separate rings, so the lowest host among
+// the seeds and this node is the bootstrap node: other nodes only join a seed that is alone if it is the bootstrap node,
+// and the bootstrap node starts the ring alone when one round of seeds finds no existing ring to join
+func (server *Server) JoinAny(seeds []string) error {
+	if len(seeds) == 0 {
+		return fmt.Errorf("Chord join failed: %s", ErrNoSeeds)
+	}
+
+	candidates := []string{}
+	bootstrap := server.config.Host
+	seen := map[string]bool{server.config.Host: true}
+	server.AddPeers(seeds...)
+	for _, seed := range seeds {
+		if !seen[seed] {
+			seen[seed] = true
+			candidates = append(candidates, seed)
+		}
+		if seed < bootstrap {
+			bootstrap = seed
+		}
+	}
+
+	deadline := time.Now().Add(durationOrDefault(server.config.JoinTimeout, DefaultJoinTimeout))
+	backoff := durationOrDefault(server.config.JoinBackoff, DefaultJoinBackoff)
+	maxBackoff := durationOrDefault(server.config.MaxJoinBackoff, DefaultMaxJoinBackoff)
+	for {
+		err := ErrNoSeeds
+		for _, i := range rand.Perm(len(candidates)) {
+			seed := candidates[i]
+			if seed != bootstrap {
+				if err = server.checkSeedInRing(seed); err != nil {
+					continue
+				}
+			}
+			if err = server.Join(seed); err == nil {
+				return nil
+			}
+			log.Printf("[ERROR]join.%s.%s", seed, err)
+		}
+
+		if server.config.Host == bootstrap {
+			log.Printf("[Join]host %s found no Chord ring to join, starting a new one", server.config.Host)
+			server.CreateRing()
+			return nil
+		}
+
+		// wait between half and the full backoff, so that nodes failing together do not retry together
+		wait := backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+		if time.Now().Add(wait).After(deadline) {
+			return fmt.Errorf("Chord join failed: no seed reachable before deadline: %s", err)
+		}
+		time.Sleep(wait)
+
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// errSeedAlone is returned when a seed that is not the bootstrap node is not part of a ring yet
+var errSeedAlone = errors.New("seed is not in a ring yet")
+
+// checkSeedInRing checks that the node on seed has joined a ring, that is, its successor or predecessor is another node.
+// The predecessor is checked too, as a node that was just joined only learns its new successor by stabilizing
+func (server *Server) checkSeedInRing(seed string) error {
+	succResp, err := server.transporter.SendGetSuccessorRequest(server, seed)
+	if err != nil {
+		return err
+	}
+	if succResp.host != "" && succResp.host != seed {
+		return nil
+	}
+
+	// a node without predecessor fails the request, which only means it is alone
+	predResp, err := server.transporter.SendGetPredecessorRequest(server, seed)
+	if err == nil && predResp.host != "" && predResp.host != seed {
+		return nil
+	}
+	return errSeedAlone
+}
+
+// JoinSeeds joins an existing chord ring through the seeds listed in the config and the seed file
+func (server *Server) JoinSeeds() error {
+	seeds, err := server.config.LoadSeeds()
+	if err != nil {
+		return fmt.Errorf("Chord join failed: %s", err)
+	}
+	return server.JoinAny(seeds)
+}
+
+// LoadSeeds returns the seeds listed in the config followed by the seeds read from the seed file.
+// The seed file lists one host per line, blank lines and lines starting with '#' are skipped
+func (config *Config) LoadSeeds() ([]string, error) {
+	seeds := append([]string{}, config.Seeds...)
+	if config.SeedFile == "" {
+		return seeds, nil
+	}
+
+	file, err := os.Open(config.SeedFile)
+	if err != nil {
+		return nil, fmt.Errorf("load seeds failed: %s", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		seeds = append(seeds, line)
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("load seeds failed: %s", err)
+	}
+	return seeds, nil
+}
@@ -0,0 +1,119 @@
+package chord
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestJoinAnySkipsUnreachableSeeds(t *testing.T) {
+	nodes := newTestNodes(t, 3, nil)
+	defer stopTestNodes(nodes)
+	joinTestNodes(t, nodes[:2])
+
+	seeds := []string{"http://localhost:1", nodes[0].server.config.Host, "http://localhost:2"}
+	if err := nodes[2].server.JoinAny(seeds); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, 10*time.Second, "ring to stabilize", func() bool {
+		return testRingStable(nodes)
+	})
+}
+
+func TestJoinAnyDeadline(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[0].server
+	server.config.JoinTimeout = 300 * time.Millisecond
+	server.config.JoinBackoff = 20 * time.Millisecond
+
+	start := time.Now()
+	if err := server.JoinAny([]string{"http://127.0.0.1:1"}); err == nil {
+		t.Errorf("expected join to fail without reachable seeds")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("expected join to give up at the deadline, took %s", elapsed)
+	}
+
+	if err := server.JoinAny(nil); err == nil {
+		t.Errorf("expected join without seeds to fail")
+	}
+	if err := server.JoinAny([]string{server.config.Host}); err != nil {
+		t.Errorf("expected node to start a ring alone when it is its only seed, got %s", err)
+	}
+}
+
+func TestJoinAnySkipsSeedsNotInRing(t *testing.T) {
+	nodes := newTestNodes(t, 2, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[1].server
+	server.config.JoinTimeout = 300 * time.Millisecond
+	server.config.JoinBackoff = 20 * time.Millisecond
+
+	// the seed is alone, and the lower host is down, so joining the seed could split the ring
+	seeds := []string{"http://0.0.0.0:1", nodes[0].server.config.Host}
+	if err := server.JoinAny(seeds); err == nil {
+		t.Errorf("expected join to skip a seed that is not in a ring")
+	}
+}
+
+func TestJoinAnySimultaneousStart(t *testing.T) {
+	nodes := newTestNodes(t, 4, nil)
+	defer stopTestNodes(nodes)
+
+	seeds := []string{}
+	for _, node := range nodes {
+		seeds = append(seeds, node.server.config.Host)
+	}
+
+	var wg sync.WaitGroup
+	for _, node := range nodes {
+		wg.Add(1)
+		go func(server *Server) {
+			defer wg.Done()
+			if err := server.JoinAny(seeds); err != nil {
+				t.Error(err)
+			}
+		}(node.server)
+	}
+	wg.Wait()
+
+	waitFor(t, 10*time.Second, "ring to stabilize", func() bool {
+		return testRingStable(nodes)
+	})
+}
+
+func TestLoadSeeds(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "seeds")
+	data := "# seed nodes\nhttp://localhost:4000\n\n  http://localhost:5000  \n"
+	if err = ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	config := DefaultConfig("http://localhost:3000")
+	config.Seeds = []string{"http://localhost:3000"}
+	config.SeedFile = path
+	seeds, err := config.LoadSeeds()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"http://localhost:3000", "http://localhost:4000", "http://localhost:5000"}
+	if !reflect.DeepEqual(seeds, expected) {
+		t.Errorf("expected seeds %v, got %v", expected, seeds)
+	}
+
+	config.SeedFile = filepath.Join(dir, "missing")
+	if _, err = config.LoadSeeds(); err == nil {
+		t.Errorf("expected missing seed file to fail")
+	}
+}
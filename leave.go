@@ -0,0 +1,167 @@
+package chord
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/wang502/chord/protobuf"
+)
+
+// LeaveRequest represents a request sent by a node leaving the ring to its successor and predecessor,
+// handing them over to each other
+type LeaveRequest struct {
+	ID          []byte
+	host        string
+	targetHost  string
+	predecessor *RemoteNode
+	successor   *RemoteNode
+}
+
+// NewLeaveRequest initializes a new leave request of the node with id on host, sent to targetHost
+func NewLeaveRequest(id []byte, host string, targetHost string, predecessor *RemoteNode, successor *RemoteNode) *LeaveRequest {
+	return &LeaveRequest{
+		ID:          id,
+		host:        host,
+		targetHost:  targetHost,
+		predecessor: predecessor,
+		successor:   successor,
+	}
+}
+
+// Encode encodes LeaveRequest into data buffer
+func (req *LeaveRequest) Encode(w io.Writer) (int, error) {
+	pbReq := &pb.LeaveRequest{
+		ID:         req.ID,
+		Host:       req.host,
+		TargetHost: req.targetHost,
+	}
+	if req.predecessor != nil {
+		pbReq.PredecessorID = req.predecessor.ID
+		pbReq.PredecessorHost = req.predecessor.host
+	}
+	if req.successor != nil {
+		pbReq.SuccessorID = req.successor.ID
+		pbReq.SuccessorHost = req.successor.host
+	}
+	data, err := proto.Marshal(pbReq)
+	if err != nil {
+		return -1, fmt.Errorf("encode LeaveRequest failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes data from buffer and stores it in LeaveRequest
+func (req *LeaveRequest) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode LeaveRequest failed: %s", err)
+	}
+
+	pbReq := &pb.LeaveRequest{}
+	if err = proto.Unmarshal(data, pbReq); err != nil {
+		return -1, fmt.Errorf("decode LeaveRequest failed: %s", err)
+	}
+
+	req.ID = pbReq.ID
+	req.host = pbReq.Host
+	req.targetHost = pbReq.TargetHost
+	req.predecessor = nil
+	if pbReq.PredecessorHost != "" {
+		req.predecessor = NewRemoteNode(pbReq.PredecessorID, pbReq.PredecessorHost)
+	}
+	req.successor = nil
+	if pbReq.SuccessorHost != "" {
+		req.successor = NewRemoteNode(pbReq.SuccessorID, pbReq.SuccessorHost)
+	}
+	return len(data), nil
+}
+
+// Leave leaves the chord ring, handing this node's successor and predecessor over to each other,
+// so that the ring closes over the gap without waiting for stabilizing to notice the node is gone.
+// The node is alone afterwards
+func (server *Server) Leave() error {
+	node := server.node
+	host := server.config.Host
+	succ, pred := node.Successor(), node.Predecessor()
+
+	targets := []string{}
+	if succ != nil && succ.host != host {
+		targets = append(targets, succ.host)
+	}
+	if pred != nil && pred.host != host && (succ == nil || pred.host != succ.host) {
+		targets = append(targets, pred.host)
+	}
+
+	var err error
+	for _, target := range targets {
+		req := NewLeaveRequest(node.GetID(), host, target, pred, succ)
+		if sendErr := server.transporter.SendLeaveRequest(server, req); sendErr != nil {
+			err = fmt.Errorf("Chord leave failed: %s", sendErr)
+		}
+	}
+
+	node.SetSuccessor(defaultSuccessor(node.GetID(), host))
+	node.SetPredecessor(nil)
+	node.Lock()
+	node.finger = make([]*FingerEntry, server.config.HashBits)
+	node.Unlock()
+	server.setJoined(false)
+	return err
+}
+
+// leave hands the neighbours of a leaving node over to this node, through the event loop like notify
+func (server *Server) leave(req *LeaveRequest) error {
+	if err := server.verifyPeer(req.ID, req.host); err != nil {
+		return err
+	}
+	_, err := server.sendCommand(req)
+	return err
+}
+
+// processLeaveRequest replaces the leaving node with its own predecessor or successor, wherever this node references it
+func (server *Server) processLeaveRequest(req *LeaveRequest) error {
+	node := server.node
+	host := server.config.Host
+	leaving := NewRemoteNode(req.ID, req.host)
+
+	if pred := node.Predecessor(); sameRemoteNode(pred, leaving) {
+		if req.predecessor == nil || req.predecessor.host == host {
+			// the leaving node was the only other node in the ring
+			node.SetPredecessor(nil)
+		} else {
+			node.SetPredecessor(req.predecessor)
+		}
+	}
+	if succ := node.Successor(); sameRemoteNode(succ, leaving) {
+		if req.successor == nil || req.successor.host == host {
+			node.SetSuccessor(defaultSuccessor(node.GetID(), host))
+		} else {
+			node.SetSuccessor(req.successor)
+		}
+	}
+
+	node.Lock()
+	for _, entry := range node.finger {
+		if entry == nil || !bytes.Equal(entry.node, leaving.ID) || entry.host != leaving.host {
+			continue
+		}
+		entry.candidates = nil
+		if req.successor != nil {
+			entry.node, entry.host = req.successor.ID, req.successor.host
+		} else {
+			entry.node, entry.host = node.ID, host
+		}
+	}
+	node.Unlock()
+
+	if server.lookupCache != nil {
+		server.lookupCache.InvalidateHost(leaving.host)
+	}
+	server.forgetPeer(leaving.host)
+	server.resetSchedules()
+	return nil
+}
@@ -0,0 +1,132 @@
+package chord
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// DefaultLookupCacheSize is the max number of owners kept in the lookup cache if not configured
+const DefaultLookupCacheSize = 1024
+
+// LookupCacheStats represents the statistics of a node's lookup cache
+type LookupCacheStats struct {
+	Hits    uint64
+	Misses  uint64
+	Entries int
+}
+
+// lookupCacheEntry caches that owner is responsible for the keys in [start, owner.ID]
+type lookupCacheEntry struct {
+	start   []byte
+	owner   *RemoteNode
+	expires time.Time
+}
+
+// lookupCache maps ranges of IDs to their owners, learned from the results of FindSuccessor
+type lookupCache struct {
+	ttl     time.Duration
+	size    int
+	entries map[string]*lookupCacheEntry
+	hits    uint64
+	misses  uint64
+	sync.Mutex
+}
+
+// newLookupCache initializes a lookup cache whose entries expire after ttl
+func newLookupCache(ttl time.Duration, size int) *lookupCache {
+	if size <= 0 {
+		size = DefaultLookupCacheSize
+	}
+	return &lookupCache{
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]*lookupCacheEntry),
+	}
+}
+
+// contains checks whether key falls in the range [start, owner.ID] of the entry
+func (e *lookupCacheEntry) contains(key []byte) bool {
+	return bytes.Equal(e.start, key) || betweenRightIncl(e.start, e.owner.ID, key)
+}
+
+// Get returns the cached owner of key, or nil on a miss
+func (c *lookupCache) Get(key []byte) *RemoteNode {
+	c.Lock()
+	defer c.Unlock()
+	now := time.Now()
+	for host, e := range c.entries {
+		if now.After(e.expires) {
+			delete(c.entries, host)
+			continue
+		}
+		if e.contains(key) {
+			c.hits++
+			return e.owner
+		}
+	}
+	c.misses++
+	return nil
+}
+
+// Put records that owner is responsible for key, which extends the cached range of owner down to key
+func (c *lookupCache) Put(key []byte, owner *RemoteNode) {
+	c.Lock()
+	defer c.Unlock()
+	expires := time.Now().Add(c.ttl)
+
+	if e, ok := c.entries[owner.host]; ok && bytes.Equal(e.owner.ID, owner.ID) {
+		// both key and start are owned by owner, so whichever is not contained in the other's range starts the union
+		if !e.contains(key) {
+			e.start = key
+		}
+		e.expires = expires
+		return
+	}
+
+	if len(c.entries) >= c.size {
+		c.evictOldest()
+	}
+	c.entries[owner.host] = &lookupCacheEntry{start: key, owner: owner, expires: expires}
+}
+
+// Invalidate drops the entries a membership change of node affects:
+// the entries owned by node, and the entries whose range node now takes a part of
+func (c *lookupCache) Invalidate(node *RemoteNode) {
+	c.Lock()
+	defer c.Unlock()
+	for host, e := range c.entries {
+		if host == node.host || e.contains(node.ID) {
+			delete(c.entries, host)
+		}
+	}
+}
+
+// InvalidateHost drops the entries owned by host
+func (c *lookupCache) InvalidateHost(host string) {
+	c.Lock()
+	defer c.Unlock()
+	delete(c.entries, host)
+}
+
+// Stats returns the hit and miss statistics of the cache
+func (c *lookupCache) Stats() LookupCacheStats {
+	c.Lock()
+	defer c.Unlock()
+	return LookupCacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Entries: len(c.entries),
+	}
+}
+
+// evictOldest drops the entry expiring first, must be called with the lock held
+func (c *lookupCache) evictOldest() {
+	var oldest string
+	for host, e := range c.entries {
+		if oldest == "" || e.expires.Before(c.entries[oldest].expires) {
+			oldest = host
+		}
+	}
+	delete(c.entries, oldest)
+}
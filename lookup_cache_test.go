@@ -0,0 +1,89 @@
+package chord
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLookupCacheRange(t *testing.T) {
+	c := newLookupCache(time.Minute, 0)
+	owner := NewRemoteNode([]byte{50}, "host50")
+
+	c.Put([]byte{40}, owner)
+	if c.Get([]byte{45}) != owner || c.Get([]byte{40}) != owner || c.Get([]byte{50}) != owner {
+		t.Error("expected hits inside [40, 50]")
+	}
+	if c.Get([]byte{30}) != nil {
+		t.Error("expected miss outside [40, 50]")
+	}
+
+	// a lookup of 30 resolving to the same owner extends the range
+	c.Put([]byte{30}, owner)
+	if c.Get([]byte{35}) != owner {
+		t.Error("expected hit inside extended range [30, 50]")
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 4 || stats.Misses != 1 || stats.Entries != 1 {
+		t.Errorf("wrong stats %+v", stats)
+	}
+}
+
+func TestLookupCacheInvalidate(t *testing.T) {
+	c := newLookupCache(time.Minute, 0)
+	c.Put([]byte{30}, NewRemoteNode([]byte{50}, "host50"))
+	c.Put([]byte{60}, NewRemoteNode([]byte{70}, "host70"))
+
+	// a node joining at 40 takes a part of the range of 50
+	c.Invalidate(NewRemoteNode([]byte{40}, "host40"))
+	if c.Get([]byte{35}) != nil {
+		t.Error("expected entry of host50 to be invalidated")
+	}
+	if c.Get([]byte{65}) == nil {
+		t.Error("expected entry of host70 to be kept")
+	}
+}
+
+func TestLookupCacheExpiry(t *testing.T) {
+	c := newLookupCache(time.Millisecond, 0)
+	c.Put([]byte{30}, NewRemoteNode([]byte{50}, "host50"))
+	time.Sleep(5 * time.Millisecond)
+	if c.Get([]byte{30}) != nil {
+		t.Error("expected expired entry to miss")
+	}
+}
+
+func TestFindSuccessorLookupCache(t *testing.T) {
+	inner := &countingTransport{}
+	config := DefaultConfig("host10")
+	config.HashBits = 8
+	config.LookupCacheTTL = time.Minute
+	server := NewServer("", config, inner)
+	server.node.SetID([]byte{10})
+	server.node.SetSuccessor(NewRemoteNode([]byte{50}, "host50"))
+
+	for i := 0; i < 2; i++ {
+		resp, err := server.FindSuccessor(NewFindSuccessorRequest([]byte{100}, ""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.host != "host50" {
+			t.Errorf("wrong successor %s", resp.host)
+		}
+	}
+	if inner.Calls() != 1 {
+		t.Errorf("expected second lookup to hit the cache, got %d requests", inner.Calls())
+	}
+
+	fresh := NewFindSuccessorRequest([]byte{100}, "")
+	fresh.Fresh = true
+	if _, err := server.FindSuccessor(fresh); err != nil {
+		t.Fatal(err)
+	}
+	if inner.Calls() != 2 {
+		t.Error("expected fresh lookup to bypass the cache")
+	}
+	if stats := server.LookupCacheStats(); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("wrong stats %+v", stats)
+	}
+}
@@ -0,0 +1,67 @@
+package chord
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"github.com/gorilla/mux"
+)
+
+// MetricsHandler serves metrics of a Chord server in the Prometheus text format.
+// Like HealthHandler, it is kept apart from the peer protocol and needs no authentication
+type MetricsHandler struct {
+	server *Server
+
+	metricsPath string
+}
+
+// NewMetricsHandler initializes the metrics of server
+func NewMetricsHandler(server *Server) *MetricsHandler {
+	return &MetricsHandler{
+		server:      server,
+		metricsPath: "/metrics",
+	}
+}
+
+// Install applies the metrics route to an http router
+func (m *MetricsHandler) Install(mux *mux.Router) {
+	mux.HandleFunc(m.metricsPath, m.metricsHandler).Methods("GET")
+}
+
+func (m *MetricsHandler) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.WriteMetrics(w)
+}
+
+// WriteMetrics writes the metrics of the server in the Prometheus text format
+func (m *MetricsHandler) WriteMetrics(w io.Writer) {
+	server := m.server
+	host := server.config.Host
+
+	writeMetric(w, "chord_estimated_size", "gauge", "Estimated number of nodes in the ring.")
+	fmt.Fprintf(w, "chord_estimated_size{host=%q} %g\n", host, server.EstimateSize())
+	writeMetric(w, "chord_local_estimated_size", "gauge", "Number of nodes in the ring estimated from the distances to the predecessor and successor only.")
+	fmt.Fprintf(w, "chord_local_estimated_size{host=%q} %g\n", host, server.localSizeEstimate())
+
+	stats := server.QueueStats()
+	lanes := []string{}
+	for lane := range stats.Lanes {
+		lanes = append(lanes, lane)
+	}
+	sort.Strings(lanes)
+	writeMetric(w, "chord_queue_depth", "gauge", "Number of events waiting for the event loop.")
+	for _, lane := range lanes {
+		fmt.Fprintf(w, "chord_queue_depth{host=%q,lane=%q} %d\n", host, lane, stats.Lanes[lane].Depth)
+	}
+	writeMetric(w, "chord_queue_rejected_total", "counter", "Number of events rejected because the server was overloaded.")
+	for _, lane := range lanes {
+		fmt.Fprintf(w, "chord_queue_rejected_total{host=%q,lane=%q} %d\n", host, lane, stats.Lanes[lane].Rejected)
+	}
+}
+
+// writeMetric writes the help and type lines of a metric
+func writeMetric(w io.Writer, name string, kind string, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
@@ -1,7 +1,7 @@
 package chord
 
 import (
-	"math/big"
+	"bytes"
 	"sync"
 )
 
@@ -21,16 +21,23 @@ type RemoteNode struct {
 	host string
 }
 
-// NewNode initializes a Node server involved in Chord protocol
+// NewNode initializes a Node server involved in Chord protocol.
+// Its ID is nil if the configured identity can not be loaded
 func NewNode(config *Config) *Node {
+	id, _ := generateID(config)
+	return newNode(config, id)
+}
+
+// newNode initializes a Node server with the given ID
+func newNode(config *Config, id []byte) *Node {
 	node := &Node{
-		ID: generateID(config),
+		ID: id,
 		//successor:   defaultSuccessor(config), // the successor is the node itself at the beginning
 		finger:      make([]*FingerEntry, config.HashBits),
 		predecessor: nil,
 		fingerIndex: -1,
 	}
-	node.successor = defaultSuccessor(node.ID, config.Host)
+	node.successor = defaultSuccessor(id, config.Host)
 
 	return node
 }
@@ -43,33 +50,29 @@ func NewRemoteNode(id []byte, host string) *RemoteNode {
 	}
 }
 
-// generateId is helper function that uses configured hash function to generates Id for a Node server
-func generateID(config *Config) []byte {
-	hash := config.HashFunc
-	hash.Write([]byte(config.Host))
-	b := hash.Sum(nil)
-
-	idInt := big.Int{}
-	idInt.SetBytes(b)
-
-	// Get the ceiling
-	two := big.NewInt(2)
-	ceil := big.Int{}
-	ceil.Exp(two, big.NewInt(int64(config.HashBits)), nil)
-
-	// Apply the mod
-	idInt.Mod(&idInt, &ceil)
+// Host returns the host of the remote node
+func (remote *RemoteNode) Host() string {
+	return remote.host
+}
 
-	// Add together
-	return idInt.Bytes()
+// generateId is helper function that uses configured hash function to generates Id for a Node server,
+// or returns the error of loading its configured identity
+func generateID(config *Config) ([]byte, error) {
+	id, err := config.stableID()
+	if err != nil || id != nil {
+		return id, err
+	}
+	return hashHost(config, config.Host), nil
 }
 
 /*
    Getter
 */
 
-// GetID returns the ID of the Node
+// GetID returns the ID of the Node, which changes when load balancing moves the node
 func (n *Node) GetID() []byte {
+	n.Lock()
+	defer n.Unlock()
 	return n.ID
 }
 
@@ -80,11 +83,18 @@ func (n *Node) Successor() *RemoteNode {
 	return n.successor
 }
 
-// Finger returns finger table inside the Node
+// Finger returns a copy of the finger table inside the Node, fixFinger updates the entries under the node's lock
 func (n *Node) Finger() []*FingerEntry {
 	n.Lock()
 	defer n.Unlock()
-	return n.finger
+	finger := make([]*FingerEntry, len(n.finger))
+	for i, entry := range n.finger {
+		if entry != nil {
+			copied := *entry
+			finger[i] = &copied
+		}
+	}
+	return finger
 }
 
 // Predecessor returns the predecessor
@@ -119,6 +129,14 @@ func (n *Node) SetPredecessor(pred *RemoteNode) {
 	n.predecessor = pred
 }
 
+// sameRemoteNode checks whether two remote nodes are both nil, or have the same ID and host
+func sameRemoteNode(a *RemoteNode, b *RemoteNode) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.host == b.host && bytes.Equal(a.ID, b.ID)
+}
+
 func defaultSuccessor(id []byte, host string) *RemoteNode {
 	return NewRemoteNode(id, host)
 }
@@ -133,7 +151,7 @@ func defaultFingerEntry(id []byte, exp int, config *Config) *FingerEntry {
 
 func defaultFingerTable(config *Config) []*FingerEntry {
 	hb := config.HashBits
-	id := generateID(config)
+	id, _ := generateID(config)
 	fingerTable := make([]*FingerEntry, hb)
 
 	for i := 0; i < hb; i++ {
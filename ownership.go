@@ -0,0 +1,148 @@
+package chord
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// KeyRange represents the range of keys (Start, End] owned by a node on the ring
+type KeyRange struct {
+	Start []byte
+	End   []byte
+
+	// Full is set when the range covers the whole ring, which happens when the node is alone
+	Full bool
+
+	// Known is false when the predecessor is unknown and the node is not alone,
+	// the start of the range can not be determined until stabilizing sets the predecessor
+	Known bool
+}
+
+// OwnershipResponse represents the response of the ownership endpoint, IDs and keys are hex encoded
+type OwnershipResponse struct {
+	Host  string
+	ID    string
+	Start string
+	End   string
+	Full  bool
+	Known bool
+
+	// Owned maps every key asked for to whether this node owns it
+	Owned map[string]bool
+}
+
+// Contains checks whether key falls in the range
+func (r *KeyRange) Contains(key []byte) bool {
+	if r.Full {
+		return true
+	}
+	if !r.Known {
+		return false
+	}
+	return betweenRightIncl(r.Start, r.End, key)
+}
+
+func (r *KeyRange) String() string {
+	if r.Full {
+		return "(full ring)"
+	}
+	if !r.Known {
+		return fmt.Sprintf("(?, %x]", r.End)
+	}
+	return fmt.Sprintf("(%x, %x]", r.Start, r.End)
+}
+
+// OwnedRange returns the range of keys (predecessor, self] this node is responsible for
+func (server *Server) OwnedRange() *KeyRange {
+	localNode := server.node
+	localID := localNode.GetID()
+	pred := localNode.Predecessor()
+	succ := localNode.Successor()
+	alone := succ == nil || succ.host == server.config.Host
+
+	if pred == nil {
+		if alone {
+			return &KeyRange{End: localID, Full: true, Known: true}
+		}
+		return &KeyRange{End: localID}
+	}
+	if pred.host == server.config.Host {
+		return &KeyRange{Start: localID, End: localID, Full: true, Known: true}
+	}
+	return &KeyRange{Start: pred.ID, End: localID, Known: true}
+}
+
+// Owns checks whether this node is responsible for key.
+// It returns false if the predecessor is unknown and the node is not alone
+func (server *Server) Owns(key []byte) bool {
+	return server.OwnedRange().Contains(key)
+}
+
+// ResponsibleFor returns the keys among keys that this node is responsible for
+func (server *Server) ResponsibleFor(keys [][]byte) [][]byte {
+	r := server.OwnedRange()
+	owned := [][]byte{}
+	for _, key := range keys {
+		if r.Contains(key) {
+			owned = append(owned, key)
+		}
+	}
+	return owned
+}
+
+// ownership builds the response of the ownership endpoint for the given keys
+func (server *Server) ownership(keys [][]byte) *OwnershipResponse {
+	r := server.OwnedRange()
+	resp := &OwnershipResponse{
+		Host:  server.config.Host,
+		ID:    hex.EncodeToString(server.node.GetID()),
+		Start: hex.EncodeToString(r.Start),
+		End:   hex.EncodeToString(r.End),
+		Full:  r.Full,
+		Known: r.Known,
+		Owned: make(map[string]bool),
+	}
+	for _, key := range keys {
+		resp.Owned[hex.EncodeToString(key)] = r.Contains(key)
+	}
+	return resp
+}
+
+// Range decodes the owned range in the OwnershipResponse
+func (resp *OwnershipResponse) Range() (*KeyRange, error) {
+	start, err := hex.DecodeString(resp.Start)
+	if err != nil {
+		return nil, fmt.Errorf("decode OwnershipResponse failed: %s", err)
+	}
+	end, err := hex.DecodeString(resp.End)
+	if err != nil {
+		return nil, fmt.Errorf("decode OwnershipResponse failed: %s", err)
+	}
+	return &KeyRange{Start: start, End: end, Full: resp.Full, Known: resp.Known}, nil
+}
+
+// Encode encodes the OwnershipResponse as json into data buffer
+func (resp *OwnershipResponse) Encode(w io.Writer) (int, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return -1, fmt.Errorf("encode OwnershipResponse failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes json data from buffer and stores it in OwnershipResponse
+func (resp *OwnershipResponse) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode OwnershipResponse failed: %s", err)
+	}
+
+	if err = json.Unmarshal(data, resp); err != nil {
+		return -1, fmt.Errorf("decode OwnershipResponse failed: %s", err)
+	}
+	return len(data), nil
+}
@@ -0,0 +1,88 @@
+package chord
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestOwnedRangeAlone(t *testing.T) {
+	server := NewServer("", DefaultConfig("localhost"), NewTransporter())
+
+	r := server.OwnedRange()
+	if !r.Full || !r.Known {
+		t.Errorf("a lone node should own the full ring, got %s", r)
+	}
+	if !server.Owns([]byte{5}) {
+		t.Error("a lone node should own every key")
+	}
+}
+
+func TestOwnedRangeUnknownPredecessor(t *testing.T) {
+	server := NewServer("", DefaultConfig("localhost"), NewTransporter())
+	server.node.SetID([]byte{4})
+	server.node.SetSuccessor(NewRemoteNode([]byte{6}, "host6"))
+
+	r := server.OwnedRange()
+	if r.Full || r.Known {
+		t.Errorf("range should be unknown without predecessor, got %s", r)
+	}
+	if server.Owns([]byte{4}) {
+		t.Error("ownership can not be claimed without predecessor")
+	}
+}
+
+func TestOwnsAndResponsibleFor(t *testing.T) {
+	server := NewServer("", DefaultConfig("localhost"), NewTransporter())
+	server.node.SetID([]byte{1})
+	server.node.SetSuccessor(NewRemoteNode([]byte{3}, "host3"))
+	server.node.SetPredecessor(NewRemoteNode([]byte{6}, "host6"))
+
+	// the range (6, 1] wraps around the ring
+	keys := [][]byte{{0}, {1}, {2}, {6}, {7}}
+	owned := server.ResponsibleFor(keys)
+	expected := [][]byte{{0}, {1}, {7}}
+	if len(owned) != len(expected) {
+		t.Fatalf("expected %d owned keys, got %d", len(expected), len(owned))
+	}
+	for i := range expected {
+		if !bytes.Equal(owned[i], expected[i]) {
+			t.Errorf("expected owned key %x, got %x", expected[i], owned[i])
+		}
+	}
+}
+
+func TestOwnershipHandler(t *testing.T) {
+	httpTransporter := NewTransporter()
+	server := NewServer("", DefaultConfig("localhost"), httpTransporter)
+	server.node.SetID([]byte{1})
+	server.node.SetSuccessor(NewRemoteNode([]byte{3}, "host3"))
+	server.node.SetPredecessor(NewRemoteNode([]byte{6}, "host6"))
+	router := mux.NewRouter()
+	httpTransporter.Install(server, router)
+
+	req, err := http.NewRequest("GET", "/ownership?key=07&key=02", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	resp := &OwnershipResponse{}
+	if _, err := resp.Decode(rr.Body); err != nil {
+		t.Fatal(err)
+	}
+	r, err := resp.Range()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r.Start, []byte{6}) || !bytes.Equal(r.End, []byte{1}) || !r.Known {
+		t.Errorf("wrong owned range %s", r)
+	}
+	if !resp.Owned["07"] || resp.Owned["02"] {
+		t.Errorf("wrong owned keys %v", resp.Owned)
+	}
+}
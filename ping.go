@@ -0,0 +1,62 @@
+package chord
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/wang502/chord/protobuf"
+)
+
+// PingResponse represents a response to a ping, identifying the node that answered it.
+// A node with a key identity also returns its public key and its signature of the ping's challenge
+type PingResponse struct {
+	ID        []byte
+	host      string
+	PublicKey []byte
+	Signature []byte
+}
+
+// NewPingResponse initializes a PingResponse object
+func NewPingResponse(id []byte, host string) *PingResponse {
+	return &PingResponse{
+		ID:   id,
+		host: host,
+	}
+}
+
+// Encode encodes PingResponse into data buffer
+func (resp *PingResponse) Encode(w io.Writer) (int, error) {
+	pb := &pb.PingResponse{
+		ID:        resp.ID,
+		Host:      resp.host,
+		PublicKey: resp.PublicKey,
+		Signature: resp.Signature,
+	}
+	data, err := proto.Marshal(pb)
+	if err != nil {
+		return -1, fmt.Errorf("encode PingResponse failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes data from buffer and stores it in PingResponse
+func (resp *PingResponse) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode PingResponse failed: %s", err)
+	}
+
+	pb := &pb.PingResponse{}
+	if err = proto.Unmarshal(data, pb); err != nil {
+		return -1, fmt.Errorf("decode PingResponse failed: %s", err)
+	}
+
+	resp.ID = pb.ID
+	resp.host = pb.Host
+	resp.PublicKey = pb.PublicKey
+	resp.Signature = pb.Signature
+	return len(data), nil
+}
@@ -0,0 +1,94 @@
+package chord
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// latencyRing is a Transport simulating a ring of nodes with given IDs, each host answering after its latency
+type latencyRing struct {
+	nopTransport
+	ids     []byte
+	latency map[string]time.Duration
+}
+
+func latencyRingHost(id byte) string {
+	return fmt.Sprintf("host%d", id)
+}
+
+// successorOf returns the first node in the ring whose ID is equal to or follows id
+func (r *latencyRing) successorOf(id []byte) byte {
+	for _, n := range r.ids {
+		if bytes.Compare([]byte{n}, id) >= 0 {
+			return n
+		}
+	}
+	return r.ids[0]
+}
+
+func (r *latencyRing) hostID(host string) byte {
+	for _, n := range r.ids {
+		if latencyRingHost(n) == host {
+			return n
+		}
+	}
+	return 0
+}
+
+func (r *latencyRing) SendFindSuccessorRequest(server *Server, req *FindSuccessorRequest) (*FindSuccessorResponse, error) {
+	succ := r.successorOf([]byte(req.ID))
+	return &FindSuccessorResponse{ID: string([]byte{succ}), host: latencyRingHost(succ)}, nil
+}
+
+func (r *latencyRing) SendNotifyRequest(server *Server, req *NotifyRequest) (*NotifyResponse, error) {
+	return &NotifyResponse{}, nil
+}
+
+func (r *latencyRing) SendGetSuccessorRequest(server *Server, host string) (*FindSuccessorResponse, error) {
+	time.Sleep(r.latency[host])
+	succ := r.successorOf([]byte{r.hostID(host) + 1})
+	return &FindSuccessorResponse{ID: string([]byte{succ}), host: latencyRingHost(succ)}, nil
+}
+
+func TestProximityFingerSelection(t *testing.T) {
+	ring := &latencyRing{
+		ids: []byte{10, 50, 60, 70, 140, 200},
+		latency: map[string]time.Duration{
+			"host50": 20 * time.Millisecond,
+			"host60": time.Millisecond,
+			"host70": 10 * time.Millisecond,
+		},
+	}
+	config := DefaultConfig("host10")
+	config.HashBits = 8
+	config.NumNodes = 256
+	config.ProximityCandidates = 3
+	server := NewServer("", config, ring)
+	server.node.SetID([]byte{10})
+	// lookups for keys beyond the successor are answered by the simulated ring
+	server.node.SetSuccessor(NewRemoteNode([]byte{50}, "host50"))
+
+	for i := 0; i < config.HashBits; i++ {
+		if _, err := server.fixFinger(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// finger 5 covers [42, 74), which holds 50, 60 and 70
+	candidates := server.node.Finger()[5].candidates
+	if len(candidates) != 3 {
+		t.Fatalf("expected 3 candidates for finger 5, got %d", len(candidates))
+	}
+
+	// all candidates precede 100, the fastest one is chosen
+	if next := server.closestPreceedingNode([]byte{100}); next.host != "host60" {
+		t.Errorf("expected lowest latency candidate host60, got %s", next.host)
+	}
+
+	// only 50 precedes 55, so routing still makes progress
+	if next := server.closestPreceedingNode([]byte{55}); next.host != "host50" {
+		t.Errorf("expected progress making candidate host50, got %s", next.host)
+	}
+}
@@ -0,0 +1,188 @@
+package chord
+
+import (
+	"errors"
+	"sync"
+)
+
+// DefaultQueueSize is the number of events each lane of the event queue holds if not configured
+const DefaultQueueSize = 200
+
+// ErrOverloaded is returned when the event queue of a server has no room for another event,
+// or the source of the event already has its share of the queue queued
+var ErrOverloaded = errors.New("chord: server overloaded")
+
+// localSource is the source of the commands submitted in process through Do and DoAt
+const localSource = "local"
+
+const (
+	// maintenanceLane carries the events that keep the ring together, notify and leave requests and health checks
+	maintenanceLane = iota
+
+	// commandLane carries the commands submitted through Do and DoAt
+	commandLane
+
+	numLanes
+)
+
+// laneNames names the lanes in the queue statistics
+var laneNames = [numLanes]string{"maintenance", "commands"}
+
+// LaneStats represents the statistics of one lane of the event queue
+type LaneStats struct {
+	Depth     int
+	MaxDepth  int
+	Enqueued  uint64
+	Rejected  uint64
+	Processed uint64
+}
+
+// QueueStats represents the statistics of the event queue of a server
+type QueueStats struct {
+	// Lanes maps the lane names, "maintenance" and "commands", to their statistics
+	Lanes map[string]LaneStats
+
+	// Sources maps the sources with queued events to their number of queued events
+	Sources map[string]int
+}
+
+// lane is a queue of events taking turns between their sources, so that one busy source does not delay the others
+type lane struct {
+	sources map[string][]*event
+	// order holds the sources with queued events in the order they take their turn
+	order []string
+	depth int
+	stats LaneStats
+}
+
+// eventQueue holds the events waiting for the event loop. Events are taken from the maintenance lane first,
+// and within a lane, round robin from their sources. A lane rejects events when it holds size events,
+// or when the source of the event already has perSource events queued in it
+type eventQueue struct {
+	sync.Mutex
+	lanes     [numLanes]*lane
+	size      int
+	perSource int
+
+	// ready is signalled when an event is pushed, it holds at most one signal
+	ready chan struct{}
+}
+
+func newEventQueue(size int, perSource int) *eventQueue {
+	if size <= 0 {
+		size = DefaultQueueSize
+	}
+	if perSource <= 0 || perSource > size {
+		perSource = size
+	}
+	q := &eventQueue{
+		size:      size,
+		perSource: perSource,
+		ready:     make(chan struct{}, 1),
+	}
+	for i := range q.lanes {
+		q.lanes[i] = &lane{sources: make(map[string][]*event)}
+	}
+	return q
+}
+
+// push queues ev from source in the lane, or returns ErrOverloaded
+func (q *eventQueue) push(ev *event, laneIndex int, source string) error {
+	q.Lock()
+	l := q.lanes[laneIndex]
+	queued := l.sources[source]
+	if l.depth >= q.size || len(queued) >= q.perSource {
+		l.stats.Rejected++
+		q.Unlock()
+		return ErrOverloaded
+	}
+	if len(queued) == 0 {
+		l.order = append(l.order, source)
+	}
+	l.sources[source] = append(queued, ev)
+	l.depth++
+	l.stats.Enqueued++
+	if l.depth > l.stats.MaxDepth {
+		l.stats.MaxDepth = l.depth
+	}
+	q.Unlock()
+
+	select {
+	case q.ready <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// pop takes the next event, or returns nil if the queue is empty
+func (q *eventQueue) pop() *event {
+	q.Lock()
+	defer q.Unlock()
+	for _, l := range q.lanes {
+		if l.depth == 0 {
+			continue
+		}
+		source := l.order[0]
+		queued := l.sources[source]
+		ev := queued[0]
+		queued[0] = nil
+		if queued = queued[1:]; len(queued) > 0 {
+			l.sources[source] = queued
+			// the source goes to the back of the line for its next event
+			l.order = append(l.order[1:], source)
+		} else {
+			delete(l.sources, source)
+			l.order = l.order[1:]
+		}
+		l.depth--
+		l.stats.Processed++
+		return ev
+	}
+	return nil
+}
+
+// stats returns a copy of the queue statistics
+func (q *eventQueue) stats() QueueStats {
+	q.Lock()
+	defer q.Unlock()
+	stats := QueueStats{
+		Lanes:   make(map[string]LaneStats),
+		Sources: make(map[string]int),
+	}
+	for i, l := range q.lanes {
+		laneStats := l.stats
+		laneStats.Depth = l.depth
+		stats.Lanes[laneNames[i]] = laneStats
+		for source, queued := range l.sources {
+			stats.Sources[source] += len(queued)
+		}
+	}
+	return stats
+}
+
+// QueueStats returns the depth and throughput of the event queue
+func (server *Server) QueueStats() QueueStats {
+	return server.queue.stats()
+}
+
+// eventLane returns the lane and source of a value sent to the event loop
+func eventLane(value interface{}) (int, string) {
+	switch req := value.(type) {
+	case *NotifyRequest:
+		return maintenanceLane, req.host
+	case *LeaveRequest:
+		return maintenanceLane, req.host
+	case *healthCommand:
+		return maintenanceLane, localSource
+	case *sourcedCommand:
+		return commandLane, req.source
+	}
+	return commandLane, localSource
+}
+
+// sourcedCommand is a command submitted through DoAt, queued under the address of the hop that sent it,
+// or under localSource when submitted in process
+type sourcedCommand struct {
+	Command
+	source string
+}
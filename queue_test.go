@@ -0,0 +1,121 @@
+package chord
+
+import (
+	"testing"
+	"time"
+)
+
+func newTestEvent(value interface{}) *event {
+	return &event{value: value, c: make(chan error, 1)}
+}
+
+func TestEventQueuePriority(t *testing.T) {
+	q := newEventQueue(10, 0)
+	command := newTestEvent(&slowCommand{})
+	notify := newTestEvent(&NotifyRequest{host: "a"})
+	q.push(command, commandLane, localSource)
+	q.push(notify, maintenanceLane, "a")
+
+	if ev := q.pop(); ev != notify {
+		t.Errorf("expected the notify request to go before the command queued first")
+	}
+	if ev := q.pop(); ev != command {
+		t.Errorf("expected the command after the notify request")
+	}
+	if ev := q.pop(); ev != nil {
+		t.Errorf("expected empty queue")
+	}
+}
+
+func TestEventQueueFairness(t *testing.T) {
+	q := newEventQueue(10, 0)
+	events := map[*event]string{}
+	for _, source := range []string{"a", "a", "a", "b", "c"} {
+		ev := newTestEvent(nil)
+		events[ev] = source
+		if err := q.push(ev, commandLane, source); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	order := ""
+	for ev := q.pop(); ev != nil; ev = q.pop() {
+		order += events[ev]
+	}
+	if order != "abcaa" {
+		t.Errorf("expected sources to take turns, got %s", order)
+	}
+}
+
+func TestEventQueueOverload(t *testing.T) {
+	q := newEventQueue(3, 2)
+	if err := q.push(newTestEvent(nil), commandLane, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.push(newTestEvent(nil), commandLane, "a"); err != nil {
+		t.Fatal(err)
+	}
+	if err := q.push(newTestEvent(nil), commandLane, "a"); err != ErrOverloaded {
+		t.Errorf("expected a source over its share to be rejected, got %v", err)
+	}
+	if err := q.push(newTestEvent(nil), commandLane, "b"); err != nil {
+		t.Errorf("expected another source to be accepted, got %s", err)
+	}
+	if err := q.push(newTestEvent(nil), commandLane, "c"); err != ErrOverloaded {
+		t.Errorf("expected a full lane to reject, got %v", err)
+	}
+	// a full command lane does not hold up the ring maintenance
+	if err := q.push(newTestEvent(nil), maintenanceLane, "c"); err != nil {
+		t.Errorf("expected the maintenance lane to accept, got %s", err)
+	}
+
+	stats := q.stats()
+	commands := stats.Lanes["commands"]
+	if commands.Depth != 3 || commands.MaxDepth != 3 || commands.Enqueued != 3 || commands.Rejected != 2 {
+		t.Errorf("unexpected command lane stats %+v", commands)
+	}
+	if stats.Sources["a"] != 2 || stats.Sources["c"] != 1 {
+		t.Errorf("unexpected source depths %v", stats.Sources)
+	}
+}
+
+func TestSendCommandOverloaded(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[0].server
+	server.Stop()
+	server.queue = newEventQueue(1, 0)
+	if err := server.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// the first command keeps the event loop busy, the second waits in the queue
+	done := make(chan error, 2)
+	go func() {
+		_, err := server.Do(&slowCommand{delay: 300 * time.Millisecond})
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+	go func() {
+		_, err := server.Do(&slowCommand{})
+		done <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := server.Do(&slowCommand{}); err != ErrOverloaded {
+		t.Errorf("expected ErrOverloaded on a full queue, got %v", err)
+	}
+	if time.Since(start) > 100*time.Millisecond {
+		t.Errorf("expected an overloaded server to answer without waiting")
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := <-done; err != nil {
+			t.Errorf("expected queued commands to run, got %s", err)
+		}
+	}
+	if stats := server.QueueStats(); stats.Lanes["commands"].Rejected != 1 {
+		t.Errorf("expected the rejection to be counted, got %+v", stats.Lanes["commands"])
+	}
+}
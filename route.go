@@ -0,0 +1,232 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"net"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/wang502/chord/protobuf"
+)
+
+// ErrNoApplication is returned when a message reaches the owner of its key, but no Application is set there
+var ErrNoApplication = errors.New("no application to deliver to")
+
+// ErrMessageDropped is returned when a Forward upcall drops a message on its way
+var ErrMessageDropped = errors.New("message dropped")
+
+// Message represents an application message routed to the node owning Key
+type Message struct {
+	Key     []byte
+	Payload []byte
+
+	// Source is the host of the node that routed the message
+	Source string
+
+	// Hops is the number of nodes the message passed before reaching this node
+	Hops int
+}
+
+// Application receives the messages routed to the keys this node owns, following the key based routing API
+type Application interface {
+	// Deliver is called on the node owning the key of msg
+	Deliver(msg *Message) error
+}
+
+// Forwarder is optionally implemented by an Application to see messages passing through this node.
+// Forward is called before msg is sent on to next, and may change the payload of msg. It returns the node
+// to send msg to, which is next unless the application picks another one, or nil to drop msg
+type Forwarder interface {
+	Forward(msg *Message, next *RemoteNode) *RemoteNode
+}
+
+// RouteRequest represents an application message passed on to the next hop towards the owner of its key
+type RouteRequest struct {
+	Key        []byte
+	Payload    []byte
+	source     string
+	targetHost string
+	hops       int
+}
+
+// RouteResponse represents the outcome of delivering a message, passed back along the hops it took.
+// A message that reached its owner but failed to be delivered, or was dropped on its way, carries the error
+// here rather than as a transport error, so that no hop sends it again
+type RouteResponse struct {
+	err string
+}
+
+// NewRouteRequest initializes a new request routing payload to the owner of key, sent to targetHost
+func NewRouteRequest(key []byte, payload []byte, source string, targetHost string) *RouteRequest {
+	return &RouteRequest{
+		Key:        key,
+		Payload:    payload,
+		source:     source,
+		targetHost: targetHost,
+	}
+}
+
+// Encode encodes RouteRequest into data buffer
+func (req *RouteRequest) Encode(w io.Writer) (int, error) {
+	pbReq := &pb.RouteRequest{
+		Key:        req.Key,
+		Payload:    req.Payload,
+		Source:     req.source,
+		TargetHost: req.targetHost,
+		Hops:       int32(req.hops),
+	}
+	data, err := proto.Marshal(pbReq)
+	if err != nil {
+		return -1, fmt.Errorf("encode RouteRequest failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes data from buffer and stores it in RouteRequest
+func (req *RouteRequest) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode RouteRequest failed: %s", err)
+	}
+
+	pbReq := &pb.RouteRequest{}
+	if err = proto.Unmarshal(data, pbReq); err != nil {
+		return -1, fmt.Errorf("decode RouteRequest failed: %s", err)
+	}
+
+	req.Key = pbReq.Key
+	req.Payload = pbReq.Payload
+	req.source = pbReq.Source
+	req.targetHost = pbReq.TargetHost
+	req.hops = int(pbReq.Hops)
+	return len(data), nil
+}
+
+// Encode encodes RouteResponse into data buffer
+func (resp *RouteResponse) Encode(w io.Writer) (int, error) {
+	data, err := proto.Marshal(&pb.RouteResponse{Error: resp.err})
+	if err != nil {
+		return -1, fmt.Errorf("encode RouteResponse failed: %s", err)
+	}
+
+	return w.Write(data)
+}
+
+// Decode decodes data from buffer and stores it in RouteResponse
+func (resp *RouteResponse) Decode(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return -1, fmt.Errorf("decode RouteResponse failed: %s", err)
+	}
+
+	pbResp := &pb.RouteResponse{}
+	if err = proto.Unmarshal(data, pbResp); err != nil {
+		return -1, fmt.Errorf("decode RouteResponse failed: %s", err)
+	}
+
+	resp.err = pbResp.Error
+	return len(data), nil
+}
+
+// SetApplication sets the application that messages routed to this node are delivered to
+func (server *Server) SetApplication(app Application) {
+	server.Lock()
+	defer server.Unlock()
+	server.application = app
+}
+
+// Application returns the application set on this node, or nil
+func (server *Server) Application() Application {
+	server.RLock()
+	defer server.RUnlock()
+	return server.application
+}
+
+// Route sends payload to the node owning key, where it is delivered to the Application. The message takes
+// the same hops as a lookup, and every node it passes calls the Forward upcall of its application.
+// Route returns once the message is delivered, with the error of delivering it
+func (server *Server) Route(key []byte, payload []byte) error {
+	resp, err := server.processRouteRequest(NewRouteRequest(key, payload, server.config.Host, server.config.Host))
+	if err != nil {
+		return err
+	}
+	if resp.err != "" {
+		return fmt.Errorf("Chord route failed: %s", resp.err)
+	}
+	return nil
+}
+
+// processRouteRequest delivers the message of req if this node owns its key,
+// and sends it on to the next hop chosen by closestPreceedingNode otherwise.
+// The error of delivering or dropping the message is returned in the response, the error of reaching its owner as error
+func (server *Server) processRouteRequest(req *RouteRequest) (*RouteResponse, error) {
+	msg := &Message{
+		Key:     req.Key,
+		Payload: req.Payload,
+		Source:  req.source,
+		Hops:    req.hops,
+	}
+	app := server.Application()
+
+	if server.Owns(req.Key) {
+		if app == nil {
+			return &RouteResponse{err: fmt.Sprintf("%x on %s: %s", req.Key, server.config.Host, ErrNoApplication)}, nil
+		}
+		if err := app.Deliver(msg); err != nil {
+			return &RouteResponse{err: fmt.Sprintf("%x on %s: %s", req.Key, server.config.Host, err)}, nil
+		}
+		return &RouteResponse{}, nil
+	}
+
+	// a lookup takes at most one hop per bit of the ID space, more hops mean the ring is changing under the message
+	if req.hops >= 2*server.config.HashBits {
+		return nil, fmt.Errorf("Chord route failed: owner of %x not reached within %d hops", req.Key, req.hops)
+	}
+
+	localNode := server.node
+	successor := localNode.Successor()
+	next := successor
+	if !betweenRightIncl(localNode.GetID(), successor.ID, req.Key) {
+		if closestPre := server.closestPreceedingNode(req.Key); closestPre != nil {
+			next = closestPre
+		}
+	}
+
+	if forwarder, ok := app.(Forwarder); ok {
+		if next = forwarder.Forward(msg, next); next == nil {
+			return &RouteResponse{err: fmt.Sprintf("%x on %s: %s", req.Key, server.config.Host, ErrMessageDropped)}, nil
+		}
+	}
+
+	forward := NewRouteRequest(msg.Key, msg.Payload, msg.Source, next.host)
+	forward.hops = req.hops + 1
+	resp, err := server.transporter.SendRouteRequest(server, forward)
+	if err != nil && unreachable(err) && next.host != successor.host && successor.host != server.config.Host {
+		// the message never reached the finger, so the successor can take it without delivering it twice,
+		// it always makes progress, though slower than the finger
+		log.Printf("[ERROR]route.%s.%s, falling back to successor %s", next.host, err, successor.host)
+		if server.lookupCache != nil {
+			server.lookupCache.InvalidateHost(next.host)
+		}
+		forward.targetHost = successor.host
+		resp, err = server.transporter.SendRouteRequest(server, forward)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("Chord route failed: %s", err)
+	}
+	return resp, nil
+}
+
+// unreachable checks whether err means a request never reached its host, so that sending it elsewhere
+// can not deliver it twice. A request that timed out may have been delivered, and is not unreachable
+func unreachable(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	return errors.Is(err, ErrFaultDropped) || errors.Is(err, ErrFaultPartitioned)
+}
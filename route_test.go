@@ -0,0 +1,174 @@
+package chord
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+// testApplication records the messages delivered to a node
+type testApplication struct {
+	host string
+	sync.Mutex
+	delivered []*Message
+}
+
+func (app *testApplication) Deliver(msg *Message) error {
+	app.Lock()
+	defer app.Unlock()
+	app.delivered = append(app.delivered, msg)
+	return nil
+}
+
+func (app *testApplication) last() *Message {
+	app.Lock()
+	defer app.Unlock()
+	if len(app.delivered) == 0 {
+		return nil
+	}
+	return app.delivered[len(app.delivered)-1]
+}
+
+// tracingApplication appends the host of every node a message passes to its payload
+type tracingApplication struct {
+	testApplication
+	drop bool
+}
+
+func (app *tracingApplication) Forward(msg *Message, next *RemoteNode) *RemoteNode {
+	if app.drop {
+		return nil
+	}
+	msg.Payload = append(msg.Payload, []byte(" "+app.host)...)
+	return next
+}
+
+func TestRouteDeliversToOwner(t *testing.T) {
+	nodes := newTestRing(t, 5, nil)
+	defer stopTestNodes(nodes)
+
+	apps := map[string]*testApplication{}
+	for _, node := range nodes {
+		app := &testApplication{host: node.server.config.Host}
+		apps[app.host] = app
+		node.server.SetApplication(app)
+	}
+
+	for k := 1; k < 256; k += 23 {
+		key := []byte{byte(k)}
+		if err := nodes[0].server.Route(key, []byte("hello")); err != nil {
+			t.Fatal(err)
+		}
+		for _, node := range nodes {
+			if !node.server.Owns(key) {
+				continue
+			}
+			msg := apps[node.server.config.Host].last()
+			if msg == nil || msg.Key[0] != key[0] || string(msg.Payload) != "hello" {
+				t.Errorf("expected message for %x to be delivered on its owner %s", key, node.server.config.Host)
+			} else if msg.Source != nodes[0].server.config.Host {
+				t.Errorf("expected message source %s, got %s", nodes[0].server.config.Host, msg.Source)
+			}
+		}
+	}
+}
+
+func TestRouteForwardUpcalls(t *testing.T) {
+	nodes := newTestRing(t, 5, nil)
+	defer stopTestNodes(nodes)
+
+	apps := map[string]*tracingApplication{}
+	for _, node := range nodes {
+		app := &tracingApplication{testApplication: testApplication{host: node.server.config.Host}}
+		apps[app.host] = app
+		node.server.SetApplication(app)
+	}
+
+	// route to the ID of the predecessor of the source, which is the furthest key away
+	sorted := sortTestNodes(nodes)
+	source, owner := sorted[0].server, sorted[len(sorted)-1].server
+	if err := source.Route(owner.node.ID, []byte("trace")); err != nil {
+		t.Fatal(err)
+	}
+
+	msg := apps[owner.config.Host].last()
+	if msg == nil {
+		t.Fatalf("expected message to be delivered on %s", owner.config.Host)
+	}
+	trail := strings.Fields(string(msg.Payload))
+	if len(trail) < 2 || trail[1] != source.config.Host {
+		t.Errorf("expected the source to forward first, got trail %v", trail)
+	}
+	if msg.Hops != len(trail)-1 {
+		t.Errorf("expected one hop per forwarding node, got %d hops for trail %v", msg.Hops, trail)
+	}
+	for _, host := range trail[1:] {
+		if host == owner.config.Host {
+			t.Errorf("expected the owner to deliver without forwarding, got trail %v", trail)
+		}
+	}
+
+	apps[source.config.Host].drop = true
+	if err := source.Route(owner.node.ID, []byte("dropped")); err == nil || !strings.Contains(err.Error(), ErrMessageDropped.Error()) {
+		t.Errorf("expected the message to be dropped by the forward upcall, got %v", err)
+	}
+}
+
+func TestRouteNoApplication(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	defer stopTestNodes(nodes)
+
+	err := nodes[0].server.Route(nodes[1].server.node.ID, []byte("lost"))
+	if err == nil || !strings.Contains(err.Error(), ErrNoApplication.Error()) {
+		t.Errorf("expected routing to a node without application to fail, got %v", err)
+	}
+}
+
+// failingApplication fails to deliver every message, and counts the attempts
+type failingApplication struct {
+	calls *int32
+}
+
+func (app *failingApplication) Deliver(msg *Message) error {
+	atomic.AddInt32(app.calls, 1)
+	return errors.New("delivery failed on purpose")
+}
+
+func TestRouteDeliveryErrorNotRetried(t *testing.T) {
+	nodes := newTestRing(t, 8, nil)
+	defer stopTestNodes(nodes)
+
+	var calls int32
+	for _, node := range nodes {
+		node.server.SetApplication(&failingApplication{calls: &calls})
+	}
+
+	// a key owned by the node furthest from the source, so that the message passes other nodes on its way
+	sorted := sortTestNodes(nodes)
+	source, owner := sorted[0].server, sorted[len(sorted)-1].server
+	err := source.Route(owner.node.ID, []byte("once"))
+	if err == nil || !strings.Contains(err.Error(), "delivery failed on purpose") {
+		t.Errorf("expected the error of Deliver, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Errorf("expected Deliver to be called once, got %d", n)
+	}
+}
+
+func TestUnreachable(t *testing.T) {
+	if !unreachable(fmt.Errorf("a -> b: %w", ErrFaultDropped)) || !unreachable(fmt.Errorf("a -> b: %w", ErrFaultPartitioned)) {
+		t.Error("expected dropped and partitioned requests to be unreachable")
+	}
+	if unreachable(fmt.Errorf("a -> b: %w", ErrFaultTimeout)) {
+		t.Error("expected a request that timed out not to be unreachable, it may have been delivered")
+	}
+
+	tr := NewTransporter()
+	_, err := tr.SendRouteRequest(nil, NewRouteRequest([]byte{1}, nil, "", "http://127.0.0.1:1"))
+	if err == nil || !unreachable(err) {
+		t.Errorf("expected a refused connection to be unreachable, got %v", err)
+	}
+}
@@ -0,0 +1,93 @@
+package chord
+
+import (
+	"sync"
+	"time"
+)
+
+// schedule computes the interval of a periodical process.
+// The interval doubles while the process observes no change, up to max,
+// and snaps back to min as soon as a change or a failure is observed
+type schedule struct {
+	min     time.Duration
+	max     time.Duration
+	current time.Duration
+
+	// wake is signaled when the interval is changed from outside the periodical process
+	wake chan struct{}
+	sync.Mutex
+}
+
+// newSchedule initializes a schedule bounded by min and max, starting at min
+func newSchedule(min time.Duration, max time.Duration) *schedule {
+	if max < min {
+		max = min
+	}
+	return &schedule{
+		min:     min,
+		max:     max,
+		current: min,
+		wake:    make(chan struct{}, 1),
+	}
+}
+
+// Interval returns the current interval
+func (s *schedule) Interval() time.Duration {
+	s.Lock()
+	defer s.Unlock()
+	return s.current
+}
+
+// Bounds returns the min and max interval
+func (s *schedule) Bounds() (time.Duration, time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	return s.min, s.max
+}
+
+// Observe backs off the interval if nothing changed, or snaps it back to min otherwise
+func (s *schedule) Observe(changed bool) {
+	s.Lock()
+	defer s.Unlock()
+	if changed {
+		s.current = s.min
+		return
+	}
+	s.current *= 2
+	if s.current > s.max {
+		s.current = s.max
+	}
+}
+
+// Reset snaps the interval back to min and wakes up the periodical process
+func (s *schedule) Reset() {
+	s.Lock()
+	reset := s.current != s.min
+	s.current = s.min
+	s.Unlock()
+
+	if reset {
+		s.signal()
+	}
+}
+
+// SetBounds changes the min and max interval, the new bounds take effect immediately
+func (s *schedule) SetBounds(min time.Duration, max time.Duration) {
+	s.Lock()
+	if max < min {
+		max = min
+	}
+	s.min = min
+	s.max = max
+	s.current = min
+	s.Unlock()
+
+	s.signal()
+}
+
+func (s *schedule) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
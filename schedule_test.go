@@ -0,0 +1,104 @@
+package chord
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestScheduleBackoff(t *testing.T) {
+	s := newSchedule(10*time.Millisecond, 35*time.Millisecond)
+
+	s.Observe(false)
+	if s.Interval() != 20*time.Millisecond {
+		t.Errorf("expected interval to double, got %s", s.Interval())
+	}
+	s.Observe(false)
+	if s.Interval() != 35*time.Millisecond {
+		t.Errorf("expected interval to be capped at max, got %s", s.Interval())
+	}
+	s.Observe(true)
+	if s.Interval() != 10*time.Millisecond {
+		t.Errorf("expected interval to snap back to min, got %s", s.Interval())
+	}
+}
+
+func TestScheduleSetBoundsWakes(t *testing.T) {
+	s := newSchedule(10*time.Millisecond, time.Second)
+	s.Observe(false)
+
+	s.SetBounds(5*time.Millisecond, 10*time.Millisecond)
+	if s.Interval() != 5*time.Millisecond {
+		t.Errorf("expected interval to take new min, got %s", s.Interval())
+	}
+	select {
+	case <-s.wake:
+	default:
+		t.Error("expected periodical process to be woken up")
+	}
+}
+
+// stabilizeCounter counts the stabilize rounds of every server, each round asks the successor for its predecessor
+type stabilizeCounter struct {
+	Transport
+	sync.Mutex
+	rounds map[string]int
+}
+
+func (c *stabilizeCounter) SendGetPredecessorRequest(server *Server, host string) (*GetPredecessorResponse, error) {
+	c.Lock()
+	c.rounds[server.config.Host]++
+	c.Unlock()
+	return c.Transport.SendGetPredecessorRequest(server, host)
+}
+
+func (c *stabilizeCounter) count(host string) int {
+	c.Lock()
+	defer c.Unlock()
+	return c.rounds[host]
+}
+
+func TestStabilizeBacksOffAndResets(t *testing.T) {
+	counter := &stabilizeCounter{Transport: NewTransporter(), rounds: map[string]int{}}
+	nodes := newTestNodes(t, 2, counter)
+	defer stopTestNodes(nodes)
+	for _, node := range nodes {
+		node.server.SetMaxStabilizeInterval(8 * DefaultStabilizeInterval)
+	}
+	server := nodes[0].server
+
+	// a lone node does not change, so its interval backs off to the max
+	waitFor(t, 5*time.Second, "stabilize to back off", func() bool {
+		return server.StabilizeInterval() == 8*DefaultStabilizeInterval
+	})
+
+	// joining changes the ring and snaps the interval back, on the joining node right away
+	// and on the other node once it is notified
+	if err := nodes[1].server.Join(server.config.Host); err != nil {
+		t.Fatal(err)
+	}
+	if interval := nodes[1].server.StabilizeInterval(); interval != DefaultStabilizeInterval {
+		t.Errorf("expected the joining node to stabilize at %s, got %s", DefaultStabilizeInterval, interval)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for server.StabilizeInterval() != DefaultStabilizeInterval {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the interval to snap back to %s, got %s", DefaultStabilizeInterval, server.StabilizeInterval())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	waitFor(t, 5*time.Second, "ring to stabilize", func() bool { return testRingStable(nodes) })
+
+	// the interval takes effect at runtime, the loop stabilizes at the new interval instead of the backed off one
+	interval := DefaultStabilizeInterval / 5
+	server.SetStabilizeInterval(interval)
+	server.SetMaxStabilizeInterval(interval)
+	if server.StabilizeInterval() != interval {
+		t.Errorf("expected new stabilize interval, got %s", server.StabilizeInterval())
+	}
+	before := counter.count(server.config.Host)
+	time.Sleep(50 * interval)
+	if rounds := counter.count(server.config.Host) - before; rounds < 20 {
+		t.Errorf("expected about 50 rounds at %s, got %d", interval, rounds)
+	}
+}
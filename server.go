@@ -1,9 +1,11 @@
 package chord
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 )
@@ -20,6 +22,12 @@ const (
 
 	// DefaultFixFingerInterval is the interval that this server will repeat fixing its finger table
 	DefaultFixFingerInterval = 50 * time.Millisecond
+
+	// DefaultMaxStabilizeInterval is the interval that the stabilize process backs off to while the ring does not change
+	DefaultMaxStabilizeInterval = 2 * time.Second
+
+	// DefaultMaxFixFingerInterval is the interval that fixing finger table backs off to while the fingers do not change
+	DefaultMaxFixFingerInterval = 2 * time.Second
 )
 
 const (
@@ -28,6 +36,9 @@ const (
 
 	// Running denotes that Chord server is currently running
 	Running = "running"
+
+	// Draining denotes that Chord server is shutting down, it rejects new commands and finishes the queued ones
+	Draining = "draining"
 )
 
 type event struct {
@@ -43,30 +54,89 @@ type Server struct {
 	node  *Node
 	sync.RWMutex
 	config      *Config
-	transporter *Transporter
+	transporter Transport
 
-	stabilizeInterval time.Duration
-	fixFingerInterval time.Duration
+	stabilizeSchedule *schedule
+	fixFingerSchedule *schedule
 
 	stopChan chan bool
 
+	// halting is set once the server starts halting, and halted is closed once it stopped.
+	// Both are reset by Start, so that the server halts once for every start
+	halting bool
+	halted  chan bool
+
 	routineGroup sync.WaitGroup
 
-	c chan *event
+	// inflight counts the commands accepted by sendCommand that have not returned yet
+	inflight sync.WaitGroup
+
+	// lookupCache caches the owners found by FindSuccessor, nil if disabled
+	lookupCache *lookupCache
+
+	// probedHosts maps the hosts that answered a probe to the ID they answered with
+	probedHosts map[string]string
+
+	// peers are the hosts of other nodes this node has heard of, consistency checks look up this node's ID through them
+	peers map[string]bool
+
+	// health tracks the progress the health and readiness checks report on
+	health healthState
+
+	// queue holds the events waiting for the event loop
+	queue *eventQueue
+
+	// application receives the messages routed to the keys this node owns, nil if not set
+	application Application
+
+	// seenBroadcasts are the IDs of the latest broadcasts this node received, in the order of seenBroadcastOrder
+	seenBroadcasts     map[string]bool
+	seenBroadcastOrder []string
+
+	// sizeShare is the share of the ID space per node averaged with peers by gossip, 0 until the first gossip
+	sizeShare float64
+
+	// dataStore holds the data under the keys this node owns, nil if not set
+	dataStore DataStore
+
+	// identityErr is the error of loading the configured identity, the server does not start or join with it
+	identityErr error
+
+	// balanceLock makes sure this node runs one round of load balancing at a time, and does not stabilize while moving
+	balanceLock sync.Mutex
 }
 
-// NewServer initializes a new local server involved in Chord protocol
-func NewServer(name string, config *Config, transporter *Transporter) *Server {
+// NewServer initializes a new local server involved in Chord protocol,
+// the error of loading the configured identity is returned by Start and Join
+func NewServer(name string, config *Config, transporter Transport) *Server {
+	id, identityErr := generateID(config)
+	if identityErr != nil {
+		log.Printf("[ERROR]%s.%s", config.Host, identityErr)
+	}
 	server := &Server{
-		name:              name,
-		state:             Stopped,
-		node:              NewNode(config),
-		config:            config,
-		transporter:       transporter,
-		stabilizeInterval: DefaultStabilizeInterval,
-		fixFingerInterval: DefaultFixFingerInterval,
-		stopChan:          make(chan bool),
-		c:                 make(chan *event, 200),
+		name:        name,
+		state:       Stopped,
+		node:        newNode(config, id),
+		identityErr: identityErr,
+		config:      config,
+		transporter: transporter,
+		stabilizeSchedule: newSchedule(
+			durationOrDefault(config.StabilizeInterval, DefaultStabilizeInterval),
+			durationOrDefault(config.MaxStabilizeInterval, DefaultMaxStabilizeInterval),
+		),
+		fixFingerSchedule: newSchedule(
+			durationOrDefault(config.FixFingerInterval, DefaultFixFingerInterval),
+			durationOrDefault(config.MaxFixFingerInterval, DefaultMaxFixFingerInterval),
+		),
+		stopChan:    make(chan bool),
+		queue:       newEventQueue(config.QueueSize, config.MaxQueuedPerSource),
+		probedHosts: make(map[string]string),
+		peers:       make(map[string]bool),
+
+		seenBroadcasts: make(map[string]bool),
+	}
+	if config.LookupCacheTTL > 0 {
+		server.lookupCache = newLookupCache(config.LookupCacheTTL, config.LookupCacheSize)
 	}
 	return server
 }
@@ -78,35 +148,51 @@ func (server *Server) Do(command interface{}) (interface{}, error) {
 
 // Join joins an existing chord ring, given existingHost is one of the node in the ring
 func (server *Server) Join(existingHost string) error {
+	if server.identityErr != nil {
+		return fmt.Errorf("Chord join failed: %s", server.identityErr)
+	}
 	localNode := server.node
-	findSuccessorReq := NewFindSuccessorRequest(localNode.ID, existingHost)
+	localID := localNode.GetID()
+	server.AddPeers(existingHost)
+	findSuccessorReq := NewFindSuccessorRequest(localID, existingHost)
 	findSuccessorResp, err := server.transporter.SendFindSuccessorRequest(server, findSuccessorReq)
 	if err != nil {
 		return fmt.Errorf("Chord join failed: %s", err)
 	}
 
 	successorNode := NewRemoteNode([]byte(findSuccessorResp.ID), findSuccessorResp.host)
+	if err = server.verifyPeer(successorNode.ID, successorNode.host); err != nil {
+		return fmt.Errorf("Chord join failed: %s", err)
+	}
 	localNode.SetSuccessor(successorNode)
+	server.observeMembership(successorNode)
+	server.resetSchedules()
 
 	if err = server.stabilize(); err != nil {
 		return fmt.Errorf("Chord join failed: %s", err)
 	}
+	server.setJoined(true)
 	log.Printf("[Join]host %s joined Chord ring", server.config.Host)
 	return nil
 }
 
-// Leave the chord ring
-func (server *Server) Leave() error {
-	return nil
-}
-
 // Start the Chord server
 func (server *Server) Start() error {
-	if server.Running() {
-		return fmt.Errorf("Chord start failed: %s", server.state)
+	if state := server.State(); state != Stopped {
+		return fmt.Errorf("Chord start failed: %s", state)
+	}
+	if server.identityErr != nil {
+		return fmt.Errorf("Chord start failed: %s", server.identityErr)
 	}
 
 	server.stopChan = make(chan bool)
+	server.Lock()
+	server.halting = false
+	server.halted = make(chan bool)
+	server.Unlock()
+	// the periodical processes count as running from now on, until they miss their next beat
+	server.beat(&server.health.stabilizeBeat)
+	server.beat(&server.health.fixFingerBeat)
 	server.SetState(Running)
 
 	server.routineGroup.Add(1)
@@ -127,22 +213,131 @@ func (server *Server) Start() error {
 		server.eventLoop()
 	}()
 
+	if server.config.ConsistencyInterval > 0 {
+		server.routineGroup.Add(1)
+		go func() {
+			defer server.routineGroup.Done()
+			server.startPeriodicalCheckConsistency()
+		}()
+	}
+
+	if server.config.SizeGossipInterval > 0 {
+		server.routineGroup.Add(1)
+		go func() {
+			defer server.routineGroup.Done()
+			server.startPeriodicalGossipSize()
+		}()
+	}
+
+	if server.config.LoadBalanceInterval > 0 {
+		server.routineGroup.Add(1)
+		go func() {
+			defer server.routineGroup.Done()
+			server.startPeriodicalRebalance()
+		}()
+	}
+
+	if server.config.StatePath != "" {
+		restore := server.alone()
+		server.routineGroup.Add(1)
+		go func() {
+			defer server.routineGroup.Done()
+			if restore {
+				if err := server.restoreState(); err != nil {
+					log.Printf("[ERROR]%s", err)
+				}
+			}
+			server.startPeriodicalSaveState()
+		}()
+	}
+
 	return nil
 }
 
-// Stop the Chord server
+// Stop the Chord server. If the server is already halting, after a Shutdown that timed out
+// or while a Shutdown runs, Stop waits for it to stop
 func (server *Server) Stop() error {
 	log.Printf("stopping Chord server %s......", server.config.Host)
 	if server.State() == Stopped {
 		return fmt.Errorf("Chord stop failed:%s", server.State())
 	}
 
+	server.halt()
+	log.Printf("stopped Chord server %s", server.config.Host)
+	return nil
+}
+
+// halt stops the periodical processes and the event loop, and waits for them to return.
+// Only the first call halts the server, the others wait for it to be stopped
+func (server *Server) halt() {
+	server.Lock()
+	halted := server.halted
+	if server.halting {
+		server.Unlock()
+		<-halted
+		return
+	}
+	server.halting = true
+	server.Unlock()
 	close(server.stopChan)
 
 	// make sure all goroutines are stopped
 	server.routineGroup.Wait()
 	server.SetState(Stopped)
-	log.Printf("stopped Chord server %s", server.config.Host)
+	if err := server.SaveState(); err != nil {
+		log.Printf("[ERROR]%s", err)
+	}
+	close(halted)
+}
+
+// Shutdown gracefully stops the Chord server before the deadline of ctx. It stops accepting new commands,
+// waits for the commands already accepted to be processed by the event loop, stops the periodical processes,
+// and leaves the ring if LeaveOnShutdown is configured. Leaving comes after stopping the periodical processes,
+// so that stabilizing does not notify the successor about this node again once it has left.
+// If ctx is done first, the server is stopped without waiting any further and the error of ctx is returned
+func (server *Server) Shutdown(ctx context.Context) error {
+	server.Lock()
+	if server.state != Running {
+		state := server.state
+		server.Unlock()
+		return fmt.Errorf("Chord shutdown failed: %s", state)
+	}
+	server.state = Draining
+	server.Unlock()
+	log.Printf("shutting down Chord server %s......", server.config.Host)
+
+	drained := make(chan bool)
+	go func() {
+		server.inflight.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+	case <-ctx.Done():
+		go server.halt()
+		return fmt.Errorf("Chord shutdown failed: draining: %s", ctx.Err())
+	}
+
+	halted := make(chan bool)
+	go func() {
+		server.halt()
+		close(halted)
+	}()
+	select {
+	case <-halted:
+	case <-ctx.Done():
+		return fmt.Errorf("Chord shutdown failed: stopping: %s", ctx.Err())
+	}
+
+	if server.config.LeaveOnShutdown {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("Chord shutdown failed: leaving: %s", err)
+		}
+		if err := server.Leave(); err != nil {
+			return fmt.Errorf("Chord shutdown failed: %s", err)
+		}
+	}
+	log.Printf("shut down Chord server %s", server.config.Host)
 	return nil
 }
 
@@ -153,22 +348,29 @@ func (server *Server) Running() bool {
 	return server.state == Running
 }
 
-// sendCommand sends command to be executed into command channel and block waiting for result
+// sendCommand queues command for the event loop and blocks waiting for result.
+// It returns ErrOverloaded without waiting if the lane of the command is full
 func (server *Server) sendCommand(command interface{}) (interface{}, error) {
-	if !server.Running() {
-		return nil, errors.New("Chord send command failed:server is not running")
-	}
+	// the reply is buffered, so that the event loop does not block on a sender that gave up because the server stopped
 	e := &event{
 		value: command,
-		c:     make(chan error),
+		c:     make(chan error, 1),
 	}
+	laneIndex, source := eventLane(command)
 
-	select {
-	case server.c <- e:
-	case <-server.stopChan:
-		return nil, errors.New("Chord send command failed: Server Stopped")
-	default:
+	// counting the command under the lock makes sure Shutdown waits for every command accepted before draining
+	server.Lock()
+	if server.state != Running {
+		server.Unlock()
+		return nil, errors.New("Chord send command failed:server is not running")
+	}
+	if err := server.queue.push(e, laneIndex, source); err != nil {
+		server.Unlock()
+		return nil, err
 	}
+	server.inflight.Add(1)
+	server.Unlock()
+	defer server.inflight.Done()
 
 	select {
 	case <-server.stopChan:
@@ -188,17 +390,30 @@ func (server *Server) eventLoop() {
 		select {
 		case <-stopChan:
 			log.Printf("chord.PeriodicalFixFinger.stop.%s", server.config.Host)
+			// the senders of the events still queued give up on the stopped server, they must not run on a restart
+			for ev := server.queue.pop(); ev != nil; ev = server.queue.pop() {
+				ev.c <- errors.New("Chord send command failed: Server Stopped")
+			}
 			return
-		case ev := <-server.c:
+		case <-server.queue.ready:
+		}
+
+		for ev := server.queue.pop(); ev != nil; ev = server.queue.pop() {
 			switch req := ev.value.(type) {
 			case Command:
 				ev.res, err = server.processCommand(req)
 			case *NotifyRequest:
 				ev.res, err = server.processNotifyRequest(req)
+			case *LeaveRequest:
+				err = server.processLeaveRequest(req)
 			default:
 				err = errors.New("Command did not implements Apply() method")
 			}
 			ev.c <- err
+
+			if server.State() == Stopped {
+				break
+			}
 		}
 		state = server.State()
 	}
@@ -230,32 +445,39 @@ func (server *Server) periodicalFixFinger(c chan bool) {
 	c <- true
 
 	stopChan := server.stopChan
-	ticker := time.Tick(server.fixFingerInterval)
+	schedule := server.fixFingerSchedule
+	min, max := schedule.Bounds()
 
-	log.Printf("chord.PeriodicalFixFinger.host: %s.interval: %s", server.config.Host, server.fixFingerInterval)
+	log.Printf("chord.PeriodicalFixFinger.host: %s.interval: %s-%s", server.config.Host, min, max)
 
 	state := server.State()
 	for state != Stopped {
+		server.beat(&server.health.fixFingerBeat)
+		timer := time.NewTimer(schedule.Interval())
 		select {
 		case <-stopChan:
+			timer.Stop()
 			log.Printf("chord.PeriodicalFixFinger.stop.%s", server.config.Host)
 			return
-		case <-ticker:
-			err := server.fixFinger()
+		case <-schedule.wake:
+			timer.Stop()
+		case <-timer.C:
+			changed, err := server.fixFinger()
 			if err != nil {
 				log.Printf("[ERROR]%s.chord.PeriodicalFixFinger.error.%s", server.config.Host, err)
 			}
+			schedule.Observe(changed || err != nil)
 		}
 
 		state = server.State()
 	}
 }
 
-func (server *Server) fixFinger() error {
+// fixFinger refreshes the next entry in the finger table, and reports whether the entry changed
+func (server *Server) fixFinger() (bool, error) {
 	hb := server.config.HashBits
 
 	node := server.node
-	finger := node.Finger()
 	node.fingerIndex = node.fingerIndex + 1
 	next := node.fingerIndex
 	if next >= hb {
@@ -263,24 +485,77 @@ func (server *Server) fixFinger() error {
 		next = 0
 	}
 
-	if finger[next] == nil {
-		finger[next] = &FingerEntry{
+	// entries are only read and written under the node's lock, the lookup runs without it
+	node.Lock()
+	entry := node.finger[next]
+	if entry == nil {
+		entry = &FingerEntry{
 			start: powerOffset(node.ID, next, hb),
 		}
+		node.finger[next] = entry
 	}
-	succReq := NewFindSuccessorRequest(finger[next].start, "")
+	start, host := entry.start, entry.host
+	node.Unlock()
+
+	// fingers must reflect the ring, not the lookup cache
+	succReq := NewFindSuccessorRequest(start, "")
+	succReq.Fresh = true
 	succResp, err := server.FindSuccessor(succReq)
 	if err != nil {
-		return err
+		return false, err
 	}
 
-	finger[next].node = []byte(succResp.ID)
-	finger[next].host = succResp.host
+	changed := host != succResp.host
+	server.AddPeers(succResp.host)
+	var candidates []*FingerCandidate
+	if server.config.ProximityCandidates > 1 {
+		candidates = server.fingerCandidates(next, succResp)
+	}
+	node.Lock()
+	entry.node = []byte(succResp.ID)
+	entry.host = succResp.host
+	if server.config.ProximityCandidates > 1 {
+		entry.candidates = candidates
+	}
+	node.Unlock()
 
 	//log.Printf("[DEBUG]%s's successor is %s", server.config.Host, server.node.Successor().host)
 	log.Printf("[Fix Finger]%s's finger entry at %d is %s", server.config.Host, next, succResp.host)
 
-	return nil
+	return changed, nil
+}
+
+// fingerCandidates walks the successors starting from the node found for finger i,
+// and measures the round trip time to each node inside the finger interval [start(i), start(i+1))
+func (server *Server) fingerCandidates(i int, found *FindSuccessorResponse) []*FingerCandidate {
+	hb := server.config.HashBits
+	localID := server.node.GetID()
+	start := powerOffset(localID, i, hb)
+	end := localID
+	if i+1 < hb {
+		end = powerOffset(localID, i+1, hb)
+	}
+
+	candidates := []*FingerCandidate{}
+	id, host := []byte(found.ID), found.host
+	for len(candidates) < server.config.ProximityCandidates {
+		if host == "" || host == server.config.Host || !betweenLeftIncl(start, end, id) {
+			break
+		}
+		if len(candidates) > 0 && candidates[0].host == host {
+			break
+		}
+
+		begin := time.Now()
+		succResp, err := server.transporter.SendGetSuccessorRequest(server, host)
+		if err != nil {
+			log.Printf("[ERROR]%s.chord.fingerCandidates.error.%s", server.config.Host, err)
+			break
+		}
+		candidates = append(candidates, &FingerCandidate{node: id, host: host, rtt: time.Since(begin)})
+		id, host = []byte(succResp.ID), succResp.host
+	}
+	return candidates
 }
 
 // startPeriodicalStabilize starts start the periodical stabilizing process
@@ -298,30 +573,60 @@ func (server *Server) periodicalStabilize(c chan bool) {
 	c <- true
 
 	stopChan := server.stopChan
-	ticker := time.Tick(server.stabilizeInterval)
+	schedule := server.stabilizeSchedule
+	min, max := schedule.Bounds()
 
-	log.Printf("chord.PeriodicalStabilize.host: %s.interval: %s", server.config.Host, server.stabilizeInterval)
+	log.Printf("chord.PeriodicalStabilize.host: %s.interval: %s-%s", server.config.Host, min, max)
 
 	state := server.State()
 
 	for state != Stopped {
+		server.beat(&server.health.stabilizeBeat)
+		timer := time.NewTimer(schedule.Interval())
 		select {
 		case <-stopChan:
+			timer.Stop()
 			log.Printf("chord.PeriodicalStabilize.stop.%s", server.config.Host)
 			return
-		case <-ticker:
+		case <-schedule.wake:
+			timer.Stop()
+		case <-timer.C:
+			// a node moving to another ID stabilizes itself, announcing its old ID meanwhile would bring it back
+			server.balanceLock.Lock()
+			succ, pred := server.node.Successor(), server.node.Predecessor()
 			err := server.stabilize()
+			server.balanceLock.Unlock()
 			if err != nil {
 				log.Printf("[ERROR]%s.chord.PeriodicalStabilize.error.%s", server.config.Host, err)
 			}
+			changed := !sameRemoteNode(succ, server.node.Successor()) || !sameRemoteNode(pred, server.node.Predecessor())
+			schedule.Observe(changed || err != nil)
 		}
 
 		state = server.State()
 	}
 }
 
+// observeMembership is called when this server learns about a node joining its neighbourhood
+func (server *Server) observeMembership(node *RemoteNode) {
+	server.AddPeers(node.host)
+	if server.lookupCache != nil && node.host != server.config.Host {
+		server.lookupCache.Invalidate(node)
+	}
+}
+
+// resetSchedules snaps the periodical processes back to their fastest interval after the ring changed
+func (server *Server) resetSchedules() {
+	server.stabilizeSchedule.Reset()
+	server.fixFingerSchedule.Reset()
+}
+
 // stabilize is called periodically to verify this server's immediate successor and tells the successor about this server
-func (server *Server) stabilize() error {
+func (server *Server) stabilize() (err error) {
+	defer func() {
+		server.recordStabilize(err)
+	}()
+
 	if server.node.Successor() == nil {
 		return fmt.Errorf("Chord stabilize failed: no successor")
 	}
@@ -332,36 +637,43 @@ func (server *Server) stabilize() error {
 		log.Printf("[ERROR]stabilize.error.%s", err)
 	} else if err != nil {
 		return fmt.Errorf("Chord stabilize failed: %s", err)
+	} else if err = server.verifyPeer([]byte(predResp.ID), predResp.host); err != nil {
+		log.Printf("[ERROR]stabilize.error.%s", err)
 	} else {
 		ID := []byte(predResp.ID)
 		host := predResp.host
+		server.updateAddress(ID, host)
 
 		if server.config.Host == successor.host {
 			// if this node is same as its successor, then we update the successor to be the predecessor,
 			// since there are at most 2 nodes in the ring now
 			server.node.SetSuccessor(NewRemoteNode(ID, host))
+			server.observeMembership(server.node.Successor())
 
-		} else if between(server.node.ID, successor.ID, ID) {
+		} else if between(server.node.GetID(), successor.ID, ID) {
 			// verifies server's immediate successor
 			// if the successor's predecessor has an ID bigger than this server, then it means this server's immediate successor
 			// should be updated to the one contained in the response
 
 			server.node.SetSuccessor(NewRemoteNode(ID, host))
-
+			server.observeMembership(server.node.Successor())
 		}
 	}
 
 	// notify the immediate successor about the server
-	_, err = server.transporter.SendNotifyRequest(server, NewNotifyRequest(server.node.ID, server.config.Host, server.node.Successor().host))
+	_, err = server.transporter.SendNotifyRequest(server, NewNotifyRequest(server.node.GetID(), server.config.Host, server.node.Successor().host))
 	if err != nil {
 		return fmt.Errorf("stabilize.error.%s", err)
 	}
-	log.Printf("[Stabilize]%s(%x)'s successor is %s(%x)", server.config.Host, server.node.ID, server.node.Successor().host, server.node.Successor().ID)
+	log.Printf("[Stabilize]%s(%x)'s successor is %s(%x)", server.config.Host, server.node.GetID(), server.node.Successor().host, server.node.Successor().ID)
 
 	return nil
 }
 
 func (server *Server) notify(req *NotifyRequest) (*NotifyResponse, error) {
+	if err := server.verifyPeer([]byte(req.ID), req.host); err != nil {
+		return nil, err
+	}
 	res, err := server.sendCommand(req)
 	if res != nil {
 		return res.(*NotifyResponse), err
@@ -373,17 +685,28 @@ func (server *Server) notify(req *NotifyRequest) (*NotifyResponse, error) {
 func (server *Server) processNotifyRequest(req *NotifyRequest) (*NotifyResponse, error) {
 	possiblePredID := []byte(req.ID)
 	possiblePredHost := req.host
+	server.updateAddress(possiblePredID, possiblePredHost)
 	currentPredecessor := server.node.Predecessor()
 
-	// when this node haven't set its predecessor, then new incoming notify request is from a node that should be a predecessor
-	if currentPredecessor == nil {
+	// when this node haven't set its predecessor, or is its own predecessor because it was alone,
+	// then new incoming notify request is from a node that should be a predecessor
+	alone := currentPredecessor != nil && currentPredecessor.host == server.config.Host
+	if currentPredecessor == nil || (alone && possiblePredHost != server.config.Host) {
+		if possiblePredHost != server.config.Host {
+			// another node joined this node, which makes it part of a ring
+			server.setJoined(true)
+		}
 		server.node.SetPredecessor(NewRemoteNode(possiblePredID, possiblePredHost))
-		return NewNotifyResponse(server.node.ID, server.config.Host), nil
+		server.observeMembership(server.node.Predecessor())
+		server.resetSchedules()
+		return NewNotifyResponse(server.node.GetID(), server.config.Host), nil
 	}
 	// update the predecessor if the notify request is from a node that has bigger byte value than the current predecessor
-	if between(currentPredecessor.ID, server.node.ID, possiblePredID) {
+	if between(currentPredecessor.ID, server.node.GetID(), possiblePredID) {
 		server.node.SetPredecessor(NewRemoteNode(possiblePredID, possiblePredHost))
-		return NewNotifyResponse(server.node.ID, server.config.Host), nil
+		server.observeMembership(server.node.Predecessor())
+		server.resetSchedules()
+		return NewNotifyResponse(server.node.GetID(), server.config.Host), nil
 	}
 	return &NotifyResponse{}, nil
 }
@@ -392,38 +715,141 @@ func (server *Server) processNotifyRequest(req *NotifyRequest) (*NotifyResponse,
 func (server *Server) FindSuccessor(req *FindSuccessorRequest) (*FindSuccessorResponse, error) {
 	id := []byte(req.ID)
 	localNode := server.node
+	localID := localNode.GetID()
 	resp := &FindSuccessorResponse{}
 
 	successor := localNode.Successor()
-	if betweenRightIncl(localNode.ID, successor.ID, id) {
+	if betweenRightIncl(localID, successor.ID, id) {
 		resp.ID = string(successor.ID)
 		resp.host = successor.host
 		return resp, nil
 	}
 
+	if server.lookupCache != nil && !req.Fresh {
+		if owner := server.lookupCache.Get(id); owner != nil {
+			resp.ID = string(owner.ID)
+			resp.host = owner.host
+			return resp, nil
+		}
+	}
+
 	closestPre := server.closestPreceedingNode(id)
 	if closestPre == nil {
-		resp.ID = string(localNode.ID)
+		resp.ID = string(localID)
 		resp.host = server.config.Host
 		return resp, nil
 	}
 	findSuccRequest := NewFindSuccessorRequest(id, closestPre.host)
-	return server.transporter.SendFindSuccessorRequest(server, findSuccRequest)
+	findSuccRequest.Fresh = req.Fresh
+	findSuccResp, err := server.transporter.SendFindSuccessorRequest(server, findSuccRequest)
+	if server.lookupCache != nil {
+		if err != nil {
+			server.lookupCache.InvalidateHost(closestPre.host)
+		} else {
+			server.lookupCache.Put(id, NewRemoteNode([]byte(findSuccResp.ID), findSuccResp.host))
+		}
+	}
+	return findSuccResp, err
+}
+
+// FindSuccessors finds the successors of many keys at once, and returns a map from key to its owner.
+// Keys are grouped by their next hop, and every group is forwarded in a single request
+func (server *Server) FindSuccessors(ids [][]byte) (map[string]*RemoteNode, error) {
+	localNode := server.node
+	localID := localNode.GetID()
+	owners := make(map[string]*RemoteNode)
+	groups := make(map[string][][]byte)
+
+	successor := localNode.Successor()
+	for _, id := range ids {
+		if betweenRightIncl(localID, successor.ID, id) {
+			owners[string(id)] = successor
+			continue
+		}
+
+		if server.lookupCache != nil {
+			if owner := server.lookupCache.Get(id); owner != nil {
+				owners[string(id)] = owner
+				continue
+			}
+		}
+
+		closestPre := server.closestPreceedingNode(id)
+		if closestPre == nil {
+			owners[string(id)] = NewRemoteNode(localID, server.config.Host)
+			continue
+		}
+		groups[closestPre.host] = append(groups[closestPre.host], id)
+	}
+
+	var wg sync.WaitGroup
+	var mutex sync.Mutex
+	var errs []string
+	for host, keys := range groups {
+		wg.Add(1)
+		go func(host string, keys [][]byte) {
+			defer wg.Done()
+			resp, err := server.transporter.SendBatchFindSuccessorRequest(server, NewBatchFindSuccessorRequest(keys, host))
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			if err != nil {
+				errs = append(errs, err.Error())
+				return
+			}
+			for key, owner := range resp.Owners {
+				owners[key] = owner
+				if server.lookupCache != nil {
+					server.lookupCache.Put([]byte(key), owner)
+				}
+			}
+		}(host, keys)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return owners, fmt.Errorf("Chord find successors failed: %s", strings.Join(errs, "; "))
+	}
+	return owners, nil
+}
+
+// processBatchFindSuccessorRequest handles a incoming request sent from other server to find the successors of many keys
+func (server *Server) processBatchFindSuccessorRequest(req *BatchFindSuccessorRequest) (*BatchFindSuccessorResponse, error) {
+	ids := make([][]byte, len(req.IDs))
+	for i, id := range req.IDs {
+		ids[i] = []byte(id)
+	}
+	owners, err := server.FindSuccessors(ids)
+	if err != nil {
+		return nil, err
+	}
+	return NewBatchFindSuccessorResponse(owners), nil
 }
 
 // closestPreceedingNode is a helper function to find the cloest preceding node of the node with given hashed id from finger table
 func (server *Server) closestPreceedingNode(id []byte) *RemoteNode {
 	localNode := server.node
+	localID := localNode.GetID()
 	finger := localNode.Finger()
 	for i := server.config.HashBits - 1; i >= 0; i-- {
 		if finger[i] != nil {
 			if finger[i].node != nil && finger[i].host != "" {
-				if between(localNode.ID, id, finger[i].node) {
+				if between(localID, id, finger[i].node) {
+					// prefer the lowest latency candidate of this finger that still makes progress
+					if c := finger[i].closestCandidate(localID, id); c != nil {
+						return &RemoteNode{ID: c.node, host: c.host}
+					}
 					return &RemoteNode{ID: finger[i].node, host: finger[i].host}
 				}
 			}
 		}
 	}
+
+	// the successor precedes the key as well while the finger table is not fixed yet
+	successor := localNode.Successor()
+	if successor != nil && between(localID, id, successor.ID) {
+		return successor
+	}
 	return nil
 }
 
@@ -453,6 +879,31 @@ func (server *Server) processGetSuccessorRequest() (*FindSuccessorResponse, erro
 //
 // -------------------------------------------------------------------------
 
+// StabilizeInterval returns the current interval of periodical stabilizing
+func (server *Server) StabilizeInterval() time.Duration {
+	return server.stabilizeSchedule.Interval()
+}
+
+// FixFingerInterval returns the current interval of periodical process of fixing finger table
+func (server *Server) FixFingerInterval() time.Duration {
+	return server.fixFingerSchedule.Interval()
+}
+
+// LookupCacheStats returns the hit and miss statistics of the lookup cache, or zero values if it is disabled
+func (server *Server) LookupCacheStats() LookupCacheStats {
+	if server.lookupCache == nil {
+		return LookupCacheStats{}
+	}
+	return server.lookupCache.Stats()
+}
+
+// AuthKeys returns the active keys used to sign and verify requests between nodes
+func (server *Server) AuthKeys() []string {
+	server.Lock()
+	defer server.Unlock()
+	return server.config.AuthKeys
+}
+
 // State retrieves the current state of Chord server
 func (server *Server) State() string {
 	server.Lock()
@@ -466,18 +917,40 @@ func (server *Server) State() string {
 //
 // -------------------------------------------------------------------------
 
-// SetStabilizeInterval sets the interval of periodical stabilizing
+// SetStabilizeInterval sets the interval of periodical stabilizing, it takes effect immediately.
+// The interval backs off up to the max stabilize interval while the ring does not change
 func (server *Server) SetStabilizeInterval(duration time.Duration) {
-	server.Lock()
-	defer server.Unlock()
-	server.stabilizeInterval = duration
+	_, max := server.stabilizeSchedule.Bounds()
+	server.stabilizeSchedule.SetBounds(duration, max)
+}
+
+// SetMaxStabilizeInterval sets the interval that periodical stabilizing backs off to while the ring does not change,
+// setting it to the stabilize interval disables the back off
+func (server *Server) SetMaxStabilizeInterval(duration time.Duration) {
+	min, _ := server.stabilizeSchedule.Bounds()
+	server.stabilizeSchedule.SetBounds(min, duration)
 }
 
-// SetFixFingerInterval sets the interval of periodical process of fixing finger table
+// SetFixFingerInterval sets the interval of periodical process of fixing finger table, it takes effect immediately.
+// The interval backs off up to the max fix finger interval while the fingers do not change
 func (server *Server) SetFixFingerInterval(duration time.Duration) {
+	_, max := server.fixFingerSchedule.Bounds()
+	server.fixFingerSchedule.SetBounds(duration, max)
+}
+
+// SetMaxFixFingerInterval sets the interval that fixing finger table backs off to while the fingers do not change,
+// setting it to the fix finger interval disables the back off
+func (server *Server) SetMaxFixFingerInterval(duration time.Duration) {
+	min, _ := server.fixFingerSchedule.Bounds()
+	server.fixFingerSchedule.SetBounds(min, duration)
+}
+
+// SetAuthKeys rotates the active keys used to sign and verify requests between nodes at runtime,
+// the first key signs and every key verifies
+func (server *Server) SetAuthKeys(keys []string) {
 	server.Lock()
 	defer server.Unlock()
-	server.fixFingerInterval = duration
+	server.config.AuthKeys = keys
 }
 
 // SetState sets the current state of Chord server
@@ -0,0 +1,175 @@
+package chord
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// slowCommand takes a while to apply, so that it is still queued or running when the server shuts down
+type slowCommand struct {
+	delay time.Duration
+}
+
+func (c *slowCommand) CommandName() string {
+	return "slow"
+}
+
+func (c *slowCommand) Apply(s *Server) (interface{}, error) {
+	time.Sleep(c.delay)
+	return "done", nil
+}
+
+func TestShutdownDrainsQueuedCommands(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[0].server
+
+	results := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() {
+			res, err := server.Do(&slowCommand{delay: 100 * time.Millisecond})
+			if err == nil && res != "done" {
+				err = context.Canceled
+			}
+			results <- err
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := <-results; err != nil {
+			t.Errorf("expected queued command to finish, got %s", err)
+		}
+	}
+
+	if server.State() != Stopped {
+		t.Errorf("expected server to be stopped, got %s", server.State())
+	}
+	if _, err := server.Do(&slowCommand{}); err == nil {
+		t.Errorf("expected commands to be rejected after shutdown")
+	}
+}
+
+func TestShutdownDeadline(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[0].server
+
+	go server.Do(&slowCommand{delay: time.Second})
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := server.Shutdown(ctx); err == nil {
+		t.Errorf("expected shutdown to fail when the deadline passes while draining")
+	}
+	waitFor(t, 5*time.Second, "server to stop", func() bool {
+		return server.State() == Stopped
+	})
+}
+
+func TestStopAfterShutdownDeadline(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[0].server
+
+	go server.Do(&slowCommand{delay: time.Second})
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := server.Shutdown(ctx); err == nil {
+		t.Fatal("expected shutdown to fail when the deadline passes while draining")
+	}
+
+	// the halt started by Shutdown is still waiting for the slow command
+	if err := server.Stop(); err != nil {
+		t.Errorf("expected stop to wait for the running halt, got %s", err)
+	}
+	if server.State() != Stopped {
+		t.Errorf("expected server to be stopped once Stop returned, got %s", server.State())
+	}
+	if err := server.Stop(); err == nil {
+		t.Errorf("expected stopping a stopped server to fail")
+	}
+}
+
+func TestStopDuringShutdown(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+	server := nodes[0].server
+
+	go server.Do(&slowCommand{delay: 300 * time.Millisecond})
+	time.Sleep(20 * time.Millisecond)
+
+	shutdown := make(chan error, 1)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		shutdown <- server.Shutdown(ctx)
+	}()
+	waitFor(t, time.Second, "server to drain", func() bool {
+		return server.State() == Draining
+	})
+
+	if err := server.Stop(); err != nil {
+		t.Errorf("expected stop during shutdown to succeed, got %s", err)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("expected shutdown to finish once stopped, got %s", err)
+	}
+	if server.State() != Stopped {
+		t.Errorf("expected server to be stopped, got %s", server.State())
+	}
+}
+
+func TestShutdownLeavesRing(t *testing.T) {
+	nodes := newTestRing(t, 4, nil)
+	defer stopTestNodes(nodes)
+
+	leaving := nodes[0].server
+	leaving.config.LeaveOnShutdown = true
+	pred, succ := leaving.node.Predecessor(), leaving.node.Successor()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := leaving.Shutdown(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	// the neighbours are handed over to each other right away, without waiting for stabilizing
+	rest := nodes[1:]
+	for _, node := range rest {
+		server := node.server
+		if server.config.Host == pred.host && server.node.Successor().host != succ.host {
+			t.Errorf("expected predecessor to take successor %s, got %s", succ.host, server.node.Successor().host)
+		}
+		if server.config.Host == succ.host && server.node.Predecessor().host != pred.host {
+			t.Errorf("expected successor to take predecessor %s, got %s", pred.host, server.node.Predecessor().host)
+		}
+	}
+	waitFor(t, 10*time.Second, "ring to stabilize without the leaving node", func() bool {
+		return testRingStable(rest)
+	})
+	if !leaving.alone() {
+		t.Errorf("expected the node to be alone after leaving")
+	}
+}
+
+func TestLeaveTwoNodeRing(t *testing.T) {
+	nodes := newTestRing(t, 2, nil)
+	defer stopTestNodes(nodes)
+
+	if err := nodes[0].server.Leave(); err != nil {
+		t.Fatal(err)
+	}
+	if !nodes[1].server.alone() {
+		t.Errorf("expected the remaining node to be alone")
+	}
+}
@@ -0,0 +1,155 @@
+package chord
+
+import (
+	"log"
+	"math"
+	"math/big"
+	"time"
+)
+
+// sizeLocalWeight is the weight of the local share in every gossip round
+const sizeLocalWeight = 0.05
+
+// SizeResponse represents the answer to a size gossip, the share of the answering node after averaging
+type SizeResponse struct {
+	Host  string
+	Share float64
+}
+
+// ringGap returns the distance from id1 clockwise to id2 in the ID space, the whole ring if they are equal
+func ringGap(id1 []byte, id2 []byte, hashBits int) *big.Int {
+	ceil := new(big.Int).Lsh(big.NewInt(1), uint(hashBits))
+	gap := new(big.Int).Sub(new(big.Int).SetBytes(id2), new(big.Int).SetBytes(id1))
+	gap.Mod(gap, ceil)
+	if gap.Sign() == 0 {
+		gap.Set(ceil)
+	}
+	return gap
+}
+
+// localShare returns the average fraction of the ID space between this node and its predecessor and successor.
+// The gaps between the nodes add up to the whole ring, so the average share of all nodes is exactly 1 / N
+func (server *Server) localShare() float64 {
+	if server.alone() {
+		return 1
+	}
+
+	localNode := server.node
+	localID := localNode.GetID()
+	hashBits := server.config.HashBits
+	gaps := new(big.Int)
+	samples := 0
+	if succ := localNode.Successor(); succ != nil && succ.host != server.config.Host {
+		gaps.Add(gaps, ringGap(localID, succ.ID, hashBits))
+		samples++
+	}
+	if pred := localNode.Predecessor(); pred != nil && pred.host != server.config.Host {
+		gaps.Add(gaps, ringGap(pred.ID, localID, hashBits))
+		samples++
+	}
+	if samples == 0 {
+		return 1
+	}
+
+	gapSum, _ := new(big.Float).SetInt(gaps).Float64()
+	return gapSum / float64(samples) / math.Ldexp(1, hashBits)
+}
+
+// shareToSize converts a share of the ID space to a number of nodes, there are at least two nodes
+// once there is another node, and no more nodes than IDs
+func (server *Server) shareToSize(share float64) float64 {
+	if share >= 1 {
+		return 1
+	}
+	return math.Min(math.Max(1/share, 2), math.Ldexp(1, server.config.HashBits))
+}
+
+// localSizeEstimate estimates the number of nodes in the ring from the density of IDs around this node
+func (server *Server) localSizeEstimate() float64 {
+	return server.shareToSize(server.localShare())
+}
+
+// EstimateSize returns the estimated number of nodes in the ring. Without gossip, it is estimated from
+// the distances to the predecessor and successor, which varies a lot from node to node. With SizeGossipInterval set,
+// nodes average the share of the ID space around them with random peers, and every node converges to
+// the average share of all nodes, which is one over the number of nodes
+func (server *Server) EstimateSize() float64 {
+	if server.alone() {
+		return 1
+	}
+	server.RLock()
+	share := server.sizeShare
+	server.RUnlock()
+	if share == 0 {
+		return server.localSizeEstimate()
+	}
+	return server.shareToSize(share)
+}
+
+// blendShare averages share into the gossiped share of this node, and returns the new share.
+// A little of the local share is mixed in first, so that the gossiped share follows the ring
+// as it grows or shrinks, without keeping the shares of the nodes from converging
+func (server *Server) blendShare(share float64) float64 {
+	local := server.localShare()
+	server.Lock()
+	defer server.Unlock()
+	mine := local
+	if server.sizeShare > 0 {
+		mine = (1-sizeLocalWeight)*server.sizeShare + sizeLocalWeight*local
+	}
+	if share > 0 {
+		mine = (mine + share) / 2
+	}
+	server.sizeShare = mine
+	return mine
+}
+
+// processSizeRequest averages the share of a gossiping node with the share of this node,
+// and answers with the average, which both nodes take as their new share
+func (server *Server) processSizeRequest(share float64) *SizeResponse {
+	return &SizeResponse{
+		Host:  server.config.Host,
+		Share: server.blendShare(share),
+	}
+}
+
+// gossipSize averages the share of this node with a random peer, push-pull style
+func (server *Server) gossipSize() error {
+	mine := server.blendShare(0)
+	peers := server.samplePeers(1)
+	if len(peers) == 0 {
+		return nil
+	}
+
+	resp, err := server.transporter.SendSizeRequest(server, peers[0], mine)
+	if err != nil {
+		return err
+	}
+
+	// the peer answered with the average of both shares, which this node takes as well
+	server.Lock()
+	server.sizeShare = resp.Share
+	server.Unlock()
+	return nil
+}
+
+// startPeriodicalGossipSize starts the periodical averaging of size estimates with random peers
+func (server *Server) startPeriodicalGossipSize() {
+	stopChan := server.stopChan
+	ticker := time.NewTicker(server.config.SizeGossipInterval)
+	defer ticker.Stop()
+
+	log.Printf("chord.PeriodicalGossipSize.host: %s.interval: %s", server.config.Host, server.config.SizeGossipInterval)
+
+	for {
+		select {
+		case <-stopChan:
+			log.Printf("chord.PeriodicalGossipSize.stop.%s", server.config.Host)
+			return
+		case <-ticker.C:
+			if err := server.gossipSize(); err != nil {
+				log.Printf("[ERROR]%s.chord.PeriodicalGossipSize.error.%s", server.config.Host, err)
+			}
+		}
+	}
+}
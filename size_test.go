@@ -0,0 +1,82 @@
+package chord
+
+import (
+	"math"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+)
+
+func TestRingGap(t *testing.T) {
+	if gap := ringGap([]byte{10}, []byte{20}, 8); gap.Int64() != 10 {
+		t.Errorf("expected gap 10, got %s", gap)
+	}
+	if gap := ringGap([]byte{250}, []byte{4}, 8); gap.Int64() != 10 {
+		t.Errorf("expected gap 10 across zero, got %s", gap)
+	}
+	if gap := ringGap([]byte{7}, []byte{7}, 8); gap.Int64() != 256 {
+		t.Errorf("expected the whole ring between equal IDs, got %s", gap)
+	}
+}
+
+func TestEstimateSizeSmallRings(t *testing.T) {
+	nodes := newTestNodes(t, 2, nil)
+	defer stopTestNodes(nodes)
+	if size := nodes[0].server.EstimateSize(); size != 1 {
+		t.Errorf("expected a node alone to estimate 1, got %g", size)
+	}
+
+	joinTestRing(t, nodes)
+	// the gaps to the predecessor and successor of two nodes add up to the whole ring
+	for _, node := range nodes {
+		if size := node.server.EstimateSize(); size != 2 {
+			t.Errorf("expected a two node ring to estimate 2, got %g", size)
+		}
+	}
+}
+
+func TestSizeGossipConverges(t *testing.T) {
+	nodes := newTestRing(t, 8, nil)
+	defer stopTestNodes(nodes)
+
+	for round := 0; round < 30; round++ {
+		for _, node := range nodes {
+			if err := node.server.gossipSize(); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	min, max, sum := math.Inf(1), 0.0, 0.0
+	for _, node := range nodes {
+		size := node.server.EstimateSize()
+		min, max, sum = math.Min(min, size), math.Max(max, size), sum+size
+	}
+	if max/min > 1.5 {
+		t.Errorf("expected gossip to bring the estimates together, got %g to %g", min, max)
+	}
+	if avg := sum / float64(len(nodes)); avg < 4 || avg > 16 {
+		t.Errorf("expected an estimate near %d nodes, got %g", len(nodes), avg)
+	}
+}
+
+func TestMetricsHandler(t *testing.T) {
+	nodes := newTestNodes(t, 1, nil)
+	defer stopTestNodes(nodes)
+
+	router := mux.NewRouter()
+	NewMetricsHandler(nodes[0].server).Install(router)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+
+	body := rec.Body.String()
+	expected := "chord_estimated_size{host=\"" + nodes[0].server.config.Host + "\"} 1\n"
+	if !strings.Contains(body, "# TYPE chord_estimated_size gauge") || !strings.Contains(body, expected) {
+		t.Errorf("expected the size estimate in the metrics, got\n%s", body)
+	}
+	if !strings.Contains(body, "chord_queue_depth{host=") {
+		t.Errorf("expected the queue depth in the metrics, got\n%s", body)
+	}
+}
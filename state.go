@@ -0,0 +1,187 @@
+package chord
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DefaultStateSaveInterval is the interval between two saves of the routing state if not configured
+const DefaultStateSaveInterval = 5 * time.Second
+
+// persistedState represents the routing state of a node saved on disk for a warm restart
+type persistedState struct {
+	Snapshot *NodeSnapshot
+	Peers    []string
+	Saved    time.Time
+}
+
+// SaveState writes the successor, predecessor, finger table and peers of this node to the configured state path.
+// The file is replaced atomically, so a crash while saving leaves the previous state intact
+func (server *Server) SaveState() error {
+	path := server.config.StatePath
+	if path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(&persistedState{
+		Snapshot: server.Snapshot(),
+		Peers:    server.Peers(),
+		Saved:    time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("Chord save state failed: %s", err)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("Chord save state failed: %s", err)
+	}
+	if _, err = tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("Chord save state failed: %s", err)
+	}
+	if err = tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("Chord save state failed: %s", err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("Chord save state failed: %s", err)
+	}
+	return nil
+}
+
+// loadState reads the routing state saved at path, it returns nil without error if nothing was saved yet
+func loadState(path string) (*persistedState, error) {
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load state failed: %s", err)
+	}
+
+	state := &persistedState{}
+	if err = json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("load state failed: %s", err)
+	}
+	if state.Snapshot == nil {
+		return nil, fmt.Errorf("load state failed: no snapshot")
+	}
+	return state, nil
+}
+
+// rejoinHosts returns the hosts a restarted node tries to rejoin through:
+// its saved successor and predecessor first, then its fingers and peers, without duplicates
+func (state *persistedState) rejoinHosts(self string) []string {
+	entries := append([]*SnapshotEntry{state.Snapshot.Successor, state.Snapshot.Predecessor}, state.Snapshot.Finger...)
+	hosts := []string{}
+	seen := map[string]bool{self: true, "": true}
+	for _, entry := range entries {
+		if entry != nil && !seen[entry.Host] {
+			seen[entry.Host] = true
+			hosts = append(hosts, entry.Host)
+		}
+	}
+	for _, host := range state.Peers {
+		if !seen[host] {
+			seen[host] = true
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// restoreState rejoins the ring this node was part of before it restarted, through the hosts in its saved state.
+// The saved fingers are only used to rejoin, fixing fingers rebuilds the finger table from the live ring
+func (server *Server) restoreState() error {
+	state, err := loadState(server.config.StatePath)
+	if err != nil {
+		return fmt.Errorf("Chord restore state failed: %s", err)
+	}
+	if state == nil {
+		return nil
+	}
+	// a node with a stable identity keeps its ID when its host changes
+	moved := state.Snapshot.Host != server.config.Host
+	if moved && !bytes.Equal(state.Snapshot.ID, server.node.GetID()) {
+		log.Printf("[Restore]saved state belongs to %s, not %s, ignoring it", state.Snapshot.Host, server.config.Host)
+		return nil
+	}
+
+	hosts := state.rejoinHosts(server.config.Host)
+	server.AddPeers(hosts...)
+	if len(hosts) == 0 {
+		return nil
+	}
+
+	if moved {
+		// the ring still knows this node under its previous host, so a lookup of its ID would end at that stale entry.
+		// Telling the saved neighbours about the new host first lets the node take its old place back
+		server.announceAddress(hosts)
+		if succ := state.Snapshot.Successor; succ != nil && succ.Host != state.Snapshot.Host {
+			server.node.SetSuccessor(NewRemoteNode(succ.ID, succ.Host))
+			if err = server.stabilize(); err == nil {
+				server.setJoined(true)
+				log.Printf("[Restore]host %s moved from %s and rejoined Chord ring at %s", server.config.Host, state.Snapshot.Host, succ.Host)
+				return nil
+			}
+			log.Printf("[ERROR]restore.successor.%s.%s", succ.Host, err)
+			server.node.SetSuccessor(defaultSuccessor(server.node.GetID(), server.config.Host))
+		}
+	}
+
+	for _, host := range hosts {
+		select {
+		case <-server.stopChan:
+			return nil
+		default:
+		}
+		if !server.alone() {
+			// another node, or an explicit join, brought this node into a ring meanwhile
+			return nil
+		}
+		if err = server.Join(host); err == nil {
+			log.Printf("[Restore]host %s rejoined Chord ring through %s", server.config.Host, host)
+			return nil
+		}
+		log.Printf("[ERROR]restore.join.%s.%s", host, err)
+	}
+	return fmt.Errorf("Chord restore state failed: no saved host reachable: %s", err)
+}
+
+// announceAddress notifies the nodes that knew this node under its previous host about its new host,
+// the predecessor in particular can not learn it by stabilizing against the previous host
+func (server *Server) announceAddress(hosts []string) {
+	for _, host := range hosts {
+		req := NewNotifyRequest(server.node.GetID(), server.config.Host, host)
+		if _, err := server.transporter.SendNotifyRequest(server, req); err != nil {
+			log.Printf("[ERROR]restore.announce.%s.%s", host, err)
+		}
+	}
+}
+
+// startPeriodicalSaveState saves the routing state every StateSaveInterval until the server stops
+func (server *Server) startPeriodicalSaveState() {
+	stopChan := server.stopChan
+	ticker := time.NewTicker(durationOrDefault(server.config.StateSaveInterval, DefaultStateSaveInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopChan:
+			return
+		case <-ticker.C:
+			if err := server.SaveState(); err != nil {
+				log.Printf("[ERROR]%s.chord.PeriodicalSaveState.error.%s", server.config.Host, err)
+			}
+		}
+	}
+}
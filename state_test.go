@@ -0,0 +1,84 @@
+package chord
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func TestSaveAndLoadState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	nodes := newTestRing(t, 3, nil)
+	defer stopTestNodes(nodes)
+
+	server := nodes[0].server
+	server.config.StatePath = filepath.Join(dir, "state.json")
+	if err = server.SaveState(); err != nil {
+		t.Fatal(err)
+	}
+
+	state, err := loadState(server.config.StatePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(state.Snapshot.Successor, newSnapshotEntry(server.node.Successor())) {
+		t.Errorf("expected saved successor %s, got %s", server.node.Successor().host, state.Snapshot.Successor)
+	}
+	hosts := state.rejoinHosts(server.config.Host)
+	if len(hosts) != 2 || hosts[0] != server.node.Successor().host {
+		t.Errorf("expected rejoin hosts to be the 2 other nodes starting with the successor, got %v", hosts)
+	}
+
+	if state, err = loadState(filepath.Join(dir, "missing.json")); state != nil || err != nil {
+		t.Errorf("expected no state without error for a missing file, got %v, %v", state, err)
+	}
+}
+
+func TestRestartRejoinsFromSavedState(t *testing.T) {
+	dir, err := ioutil.TempDir("", "chord")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	nodes := newTestNodes(t, 3, nil)
+	defer stopTestNodes(nodes)
+	for i, node := range nodes {
+		node.server.config.StatePath = filepath.Join(dir, fmt.Sprintf("state-%d.json", i))
+	}
+	joinTestRing(t, nodes)
+
+	// stopping saves the state, and the restarted process starts alone on the same host
+	old := nodes[0].server
+	if err = old.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	config := DefaultConfig(old.config.Host)
+	config.HashBits = 8
+	config.NumNodes = 256
+	config.StatePath = old.config.StatePath
+	transporter := NewTransporter()
+	restarted := NewServer(old.name, config, transporter)
+	router := mux.NewRouter()
+	transporter.Install(restarted, router)
+	nodes[0].http.Config.Handler = router
+	nodes[0].server = restarted
+
+	if err = restarted.Start(); err != nil {
+		t.Fatal(err)
+	}
+	waitFor(t, 10*time.Second, "restarted node to rejoin", func() bool {
+		return testRingStable(nodes)
+	})
+}
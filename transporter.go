@@ -2,7 +2,13 @@ package chord
 
 import (
 	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
 
 	"log"
 
@@ -13,21 +19,55 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// ringHeader carries the ID of the ring a message is meant for
+const ringHeader = "X-Chord-Ring"
+
+// ErrWrongRing is returned when a message is meant for another ring than the one it reached
+var ErrWrongRing = errors.New("message for another ring")
+
+// Transport represents the interface a Chord server uses to send requests to other nodes
+type Transport interface {
+	SendFindSuccessorRequest(server *Server, req *FindSuccessorRequest) (*FindSuccessorResponse, error)
+	SendNotifyRequest(server *Server, req *NotifyRequest) (*NotifyResponse, error)
+	SendGetPredecessorRequest(server *Server, host string) (*GetPredecessorResponse, error)
+	SendGetSuccessorRequest(server *Server, host string) (*FindSuccessorResponse, error)
+	SendBatchFindSuccessorRequest(server *Server, req *BatchFindSuccessorRequest) (*BatchFindSuccessorResponse, error)
+	SendPingRequest(server *Server, host string, challenge []byte) (*PingResponse, error)
+	SendLeaveRequest(server *Server, req *LeaveRequest) error
+	SendDoRequest(server *Server, req *DoRequest) (*DoResponse, error)
+	SendRouteRequest(server *Server, req *RouteRequest) (*RouteResponse, error)
+	SendBroadcastRequest(server *Server, req *BroadcastRequest) (*BroadcastResponse, error)
+	SendSizeRequest(server *Server, host string, share float64) (*SizeResponse, error)
+	SendLoadRequest(server *Server, host string) (*LoadResponse, error)
+	SendTransferRequest(server *Server, req *TransferRequest) (*TransferResponse, error)
+}
+
 // Transporter represents a http communication gate with other nodes
 type Transporter struct {
 	httpClient        http.Client
+	authKeys          []string
+	ringID            string
 	listNodesPath     string
 	findSuccessorPath string
 
+	batchFindSuccessorPath string
+	ownershipPath          string
+	pingPath               string
+	leavePath              string
+	doPath                 string
+	routePath              string
+	broadcastPath          string
+	sizePath               string
+	loadPath               string
+	transferPath           string
+
 	getPredecessorPath string
 	getSuccessorPath   string
 	setPredecessorPath string
 	getFingerTablePath string
+	getSnapshotPath    string
 
 	notifyPath string
-	joinPath   string
-	startPath  string
-	stopPath   string
 }
 
 // NewTransporter initilizes a new Transporter object
@@ -38,23 +78,79 @@ func NewTransporter() *Transporter {
 		getPredecessorPath: "/getPredecessor",
 		getSuccessorPath:   "/getSuccessor",
 		getFingerTablePath: "/getFingerTable",
+		getSnapshotPath:    "/getSnapshot",
 		notifyPath:         "/notify",
-		joinPath:           "/join",
-		startPath:          "/start",
-		stopPath:           "/stop",
+
+		batchFindSuccessorPath: "/findSuccessors",
+		ownershipPath:          "/ownership",
+		pingPath:               "/ping",
+		leavePath:              "/leave",
+		doPath:                 "/do",
+		routePath:              "/route",
+		broadcastPath:          "/broadcast",
+		sizePath:               "/size",
+		loadPath:               "/load",
+		transferPath:           "/transfer",
+	}
+}
+
+// NewRingTransporter initializes a Transporter scoped to the ring with ringID, so that one listener can host many rings.
+// Its routes are mounted under the path prefix "/ring/<ringID>", and every message carries the ring ID,
+// messages for another ring are rejected
+func NewRingTransporter(ringID string) *Transporter {
+	t := NewTransporter()
+	t.ringID = ringID
+	return t
+}
+
+// RingID returns the ID of the ring this Transporter is scoped to, empty if it is not scoped
+func (t *Transporter) RingID() string {
+	return t.ringID
+}
+
+// prefix returns the path prefix of the routes of the ring this Transporter is scoped to
+func (t *Transporter) prefix() string {
+	if t.ringID == "" {
+		return ""
 	}
+	return "/ring/" + url.PathEscape(t.ringID)
+}
+
+// url returns the url of the route with path on host, in the ring this Transporter is scoped to
+func (t *Transporter) url(host string, path string) string {
+	return host + t.prefix() + path
 }
 
 // Install applies the chord route to an http router
 func (t *Transporter) Install(server *Server, mux *mux.Router) {
-	mux.HandleFunc(t.notifyPath, t.notifyHandler(server))
-	mux.HandleFunc(t.findSuccessorPath, t.findSuccessorHandler(server))
-	mux.HandleFunc(t.getPredecessorPath, t.getPredecessorHandler(server))
-	mux.HandleFunc(t.getSuccessorPath, t.getSuccessorHandler(server))
-	mux.HandleFunc(t.joinPath, t.joinHandler(server)).Methods("POST")
-	mux.HandleFunc(t.startPath, t.startHandler(server)).Methods("POST")
-	mux.HandleFunc(t.stopPath, t.stopHandler(server)).Methods("POST")
-	mux.HandleFunc(t.getFingerTablePath, t.getFingerTableHandler(server))
+	auth := newAuthenticator(server.config.AuthMaxSkew)
+	if t.ringID != "" {
+		mux = mux.PathPrefix(t.prefix()).Subrouter()
+		mux.Use(t.checkRing)
+	}
+
+	mux.HandleFunc(t.notifyPath, auth.authenticate(server, t.notifyHandler(server)))
+	mux.HandleFunc(t.findSuccessorPath, auth.authenticate(server, t.findSuccessorHandler(server)))
+	mux.HandleFunc(t.batchFindSuccessorPath, auth.authenticate(server, t.batchFindSuccessorHandler(server)))
+	mux.HandleFunc(t.getPredecessorPath, auth.authenticate(server, t.getPredecessorHandler(server)))
+	mux.HandleFunc(t.getSuccessorPath, auth.authenticate(server, t.getSuccessorHandler(server)))
+	mux.HandleFunc(t.getFingerTablePath, auth.authenticate(server, t.getFingerTableHandler(server)))
+	mux.HandleFunc(t.getSnapshotPath, auth.authenticate(server, t.getSnapshotHandler(server)))
+	mux.HandleFunc(t.ownershipPath, auth.authenticate(server, t.ownershipHandler(server)))
+	mux.HandleFunc(t.pingPath, auth.authenticate(server, t.pingHandler(server)))
+	mux.HandleFunc(t.leavePath, auth.authenticate(server, t.leaveHandler(server))).Methods("POST")
+	mux.HandleFunc(t.doPath, auth.authenticate(server, t.doHandler(server))).Methods("POST")
+	mux.HandleFunc(t.routePath, auth.authenticate(server, t.routeHandler(server))).Methods("POST")
+	mux.HandleFunc(t.broadcastPath, auth.authenticate(server, t.broadcastHandler(server))).Methods("POST")
+	mux.HandleFunc(t.sizePath, auth.authenticate(server, t.sizeHandler(server))).Methods("POST")
+	mux.HandleFunc(t.loadPath, auth.authenticate(server, t.loadHandler(server)))
+	mux.HandleFunc(t.transferPath, auth.authenticate(server, t.transferHandler(server))).Methods("POST")
+}
+
+// SetAuthKeys sets the keys used to sign requests that are not sent on behalf of a server, such as lookups from a client
+// or debug requests. Requests sent on behalf of a server are signed with the keys in its config
+func (t *Transporter) SetAuthKeys(keys []string) {
+	t.authKeys = keys
 }
 
 // -------------------------------------------------------------------------
@@ -63,6 +159,48 @@ func (t *Transporter) Install(server *Server, mux *mux.Router) {
 //
 // -------------------------------------------------------------------------
 
+// send sends an http request on behalf of server. When authentication is enabled, the request is signed,
+// and the response is rejected unless it is signed and bound to the request
+func (t *Transporter) send(server *Server, method string, url string, body []byte) (*http.Response, error) {
+	httpReq, err := http.NewRequest(method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if method == "POST" {
+		httpReq.Header.Set("Content-Type", "chord.protobuf")
+	}
+	if t.ringID != "" {
+		httpReq.Header.Set(ringHeader, t.ringID)
+	}
+
+	keys := t.authKeys
+	if server != nil {
+		keys = server.AuthKeys()
+	}
+	if len(keys) == 0 {
+		return t.httpClient.Do(httpReq)
+	}
+
+	nonce, err := signRequest(keys, httpReq, body)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := t.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadAll(httpResp.Body)
+	httpResp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err = verifyResponse(keys, nonce, httpResp, data); err != nil {
+		return nil, fmt.Errorf("unauthenticated response from %s: %s", url, err)
+	}
+	httpResp.Body = ioutil.NopCloser(bytes.NewReader(data))
+	return httpResp, nil
+}
+
 // SendFindSuccessorRequest sends outgoing find successor request to other Node server, a successor response will be returned
 func (t *Transporter) SendFindSuccessorRequest(server *Server, req *FindSuccessorRequest) (*FindSuccessorResponse, error) {
 	var b bytes.Buffer
@@ -70,8 +208,11 @@ func (t *Transporter) SendFindSuccessorRequest(server *Server, req *FindSuccesso
 		return nil, fmt.Errorf("send successor request failed: %s", err)
 	}
 
-	url := req.host + t.findSuccessorPath
-	httpResp, err := t.httpClient.Post(url, "chord.protobuf", &b)
+	url := t.url(req.host, t.findSuccessorPath)
+	if req.Fresh {
+		url += "?fresh=true"
+	}
+	httpResp, err := t.send(server, "POST", url, b.Bytes())
 	if err != nil {
 		return nil, fmt.Errorf("send successor request failed: %s", err)
 	}
@@ -85,6 +226,28 @@ func (t *Transporter) SendFindSuccessorRequest(server *Server, req *FindSuccesso
 	return successorResp, nil
 }
 
+// SendBatchFindSuccessorRequest sends outgoing request to find the successors of many keys to other Node server
+func (t *Transporter) SendBatchFindSuccessorRequest(server *Server, req *BatchFindSuccessorRequest) (*BatchFindSuccessorResponse, error) {
+	var b bytes.Buffer
+	if _, err := req.Encode(&b); err != nil {
+		return nil, fmt.Errorf("send batch successor request failed: %s", err)
+	}
+
+	url := t.url(req.host, t.batchFindSuccessorPath)
+	httpResp, err := t.send(server, "POST", url, b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("send batch successor request failed: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	batchResp := &BatchFindSuccessorResponse{}
+	if _, err = batchResp.Decode(httpResp.Body); err != nil {
+		return nil, fmt.Errorf("send batch successor request failed: %s", err)
+	}
+
+	return batchResp, nil
+}
+
 // SendNotifyRequest sends a request to other node to nofify it about the possible new predecessor
 func (t *Transporter) SendNotifyRequest(server *Server, req *NotifyRequest) (*NotifyResponse, error) {
 	var b bytes.Buffer
@@ -92,8 +255,8 @@ func (t *Transporter) SendNotifyRequest(server *Server, req *NotifyRequest) (*No
 		return nil, fmt.Errorf("send notify request failed: %s", err)
 	}
 
-	url := req.targetHost + t.notifyPath
-	httpResp, err := t.httpClient.Post(url, "chord.protobuf", &b)
+	url := t.url(req.targetHost, t.notifyPath)
+	httpResp, err := t.send(server, "POST", url, b.Bytes())
 	if err != nil {
 		return nil, fmt.Errorf("send notify request failed: %s", err)
 	}
@@ -109,8 +272,8 @@ func (t *Transporter) SendNotifyRequest(server *Server, req *NotifyRequest) (*No
 
 // SendGetPredecessorRequest sends a request to get the predecessor of server on given host
 func (t *Transporter) SendGetPredecessorRequest(server *Server, host string) (*GetPredecessorResponse, error) {
-	url := host + t.getPredecessorPath
-	httpResp, err := t.httpClient.Get(url)
+	url := t.url(host, t.getPredecessorPath)
+	httpResp, err := t.send(server, "GET", url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("send getPredecessor request failed: %s", err)
 	}
@@ -124,6 +287,240 @@ func (t *Transporter) SendGetPredecessorRequest(server *Server, host string) (*G
 	return predResp, nil
 }
 
+// SendGetSuccessorRequest sends a request to get the successor of server on given host
+func (t *Transporter) SendGetSuccessorRequest(server *Server, host string) (*FindSuccessorResponse, error) {
+	url := t.url(host, t.getSuccessorPath)
+	httpResp, err := t.send(server, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("send getSuccessor request failed: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	succResp := &FindSuccessorResponse{}
+	if _, err = succResp.Decode(httpResp.Body); err != nil {
+		return nil, fmt.Errorf("send getSuccessor request failed: %s", err)
+	}
+
+	return succResp, nil
+}
+
+// SendPingRequest sends a ping to the server on given host, which answers with its identity,
+// and signs the challenge if it has a key identity
+func (t *Transporter) SendPingRequest(server *Server, host string, challenge []byte) (*PingResponse, error) {
+	url := t.url(host, t.pingPath) + "?challenge=" + hex.EncodeToString(challenge)
+	httpResp, err := t.send(server, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("send ping request failed: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	pingResp := &PingResponse{}
+	if _, err = pingResp.Decode(httpResp.Body); err != nil {
+		return nil, fmt.Errorf("send ping request failed: %s", err)
+	}
+
+	return pingResp, nil
+}
+
+// SendLeaveRequest sends a leave request to the server on the request's target host
+func (t *Transporter) SendLeaveRequest(server *Server, req *LeaveRequest) error {
+	var b bytes.Buffer
+	if _, err := req.Encode(&b); err != nil {
+		return fmt.Errorf("send leave request failed: %s", err)
+	}
+
+	httpResp, err := t.send(server, "POST", t.url(req.targetHost, t.leavePath), b.Bytes())
+	if err != nil {
+		return fmt.Errorf("send leave request failed: %s", err)
+	}
+	httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("send leave request failed: %s", httpResp.Status)
+	}
+	return nil
+}
+
+// SendDoRequest sends a command to the server on the request's target host,
+// which runs it if it owns the request's key and forwards it to the owner otherwise
+func (t *Transporter) SendDoRequest(server *Server, req *DoRequest) (*DoResponse, error) {
+	var b bytes.Buffer
+	if _, err := req.Encode(&b); err != nil {
+		return nil, fmt.Errorf("send do request failed: %s", err)
+	}
+
+	httpResp, err := t.send(server, "POST", t.url(req.targetHost, t.doPath), b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("send do request failed: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(httpResp.Body)
+		return nil, fmt.Errorf("send do request failed: %s: %s", httpResp.Status, bytes.TrimSpace(msg))
+	}
+	doResp := &DoResponse{}
+	if _, err = doResp.Decode(httpResp.Body); err != nil {
+		return nil, fmt.Errorf("send do request failed: %s", err)
+	}
+
+	return doResp, nil
+}
+
+// SendRouteRequest sends an application message to the server on the request's target host,
+// which delivers it if it owns the request's key and passes it on otherwise, and returns the outcome of delivering it.
+// Errors wrap their cause, so that a caller can tell a host that could not be reached from a request that may have been delivered
+func (t *Transporter) SendRouteRequest(server *Server, req *RouteRequest) (*RouteResponse, error) {
+	var b bytes.Buffer
+	if _, err := req.Encode(&b); err != nil {
+		return nil, fmt.Errorf("send route request failed: %w", err)
+	}
+
+	httpResp, err := t.send(server, "POST", t.url(req.targetHost, t.routePath), b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("send route request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(httpResp.Body)
+		return nil, fmt.Errorf("send route request failed: %s: %s", httpResp.Status, bytes.TrimSpace(msg))
+	}
+	routeResp := &RouteResponse{}
+	if _, err = routeResp.Decode(httpResp.Body); err != nil {
+		return nil, fmt.Errorf("send route request failed: %w", err)
+	}
+	return routeResp, nil
+}
+
+// SendBroadcastRequest sends a broadcast to the server on the request's target host,
+// which passes it on to the nodes it covers and answers with their aggregated acknowledgments
+func (t *Transporter) SendBroadcastRequest(server *Server, req *BroadcastRequest) (*BroadcastResponse, error) {
+	var b bytes.Buffer
+	if _, err := req.Encode(&b); err != nil {
+		return nil, fmt.Errorf("send broadcast request failed: %s", err)
+	}
+
+	httpResp, err := t.send(server, "POST", t.url(req.targetHost, t.broadcastPath), b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("send broadcast request failed: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("send broadcast request failed: %s", httpResp.Status)
+	}
+	broadcastResp := &BroadcastResponse{}
+	if _, err = broadcastResp.Decode(httpResp.Body); err != nil {
+		return nil, fmt.Errorf("send broadcast request failed: %s", err)
+	}
+
+	return broadcastResp, nil
+}
+
+// SendSizeRequest gossips the share of the ID space per node estimated by server with the server on given host,
+// which answers with the average of both shares
+func (t *Transporter) SendSizeRequest(server *Server, host string, share float64) (*SizeResponse, error) {
+	url := t.url(host, t.sizePath) + "?share=" + strconv.FormatFloat(share, 'g', -1, 64)
+	httpResp, err := t.send(server, "POST", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("send size request failed: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("send size request failed: %s", httpResp.Status)
+	}
+	sizeResp := &SizeResponse{}
+	if err = json.NewDecoder(httpResp.Body).Decode(sizeResp); err != nil {
+		return nil, fmt.Errorf("send size request failed: %s", err)
+	}
+	return sizeResp, nil
+}
+
+// SendLoadRequest fetches the load of the server on given host, and the ID that would split it
+func (t *Transporter) SendLoadRequest(server *Server, host string) (*LoadResponse, error) {
+	httpResp, err := t.send(server, "GET", t.url(host, t.loadPath), nil)
+	if err != nil {
+		return nil, fmt.Errorf("send load request failed: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("send load request failed: %s", httpResp.Status)
+	}
+	loadResp := &LoadResponse{}
+	if err = json.NewDecoder(httpResp.Body).Decode(loadResp); err != nil {
+		return nil, fmt.Errorf("send load request failed: %s", err)
+	}
+	return loadResp, nil
+}
+
+// SendTransferRequest sends a request to export, import or delete data to the server on the request's target host
+func (t *Transporter) SendTransferRequest(server *Server, req *TransferRequest) (*TransferResponse, error) {
+	var b bytes.Buffer
+	if _, err := req.Encode(&b); err != nil {
+		return nil, fmt.Errorf("send transfer request failed: %s", err)
+	}
+
+	httpResp, err := t.send(server, "POST", t.url(req.targetHost, t.transferPath), b.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("send transfer request failed: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(httpResp.Body)
+		return nil, fmt.Errorf("send transfer request failed: %s: %s", httpResp.Status, bytes.TrimSpace(msg))
+	}
+	transferResp := &TransferResponse{}
+	if err = json.NewDecoder(httpResp.Body).Decode(transferResp); err != nil {
+		return nil, fmt.Errorf("send transfer request failed: %s", err)
+	}
+	return transferResp, nil
+}
+
+// GetOwnership fetches the range of keys owned by the server on given host, and whether it owns the given keys
+func (t *Transporter) GetOwnership(host string, keys [][]byte) (*OwnershipResponse, error) {
+	query := url.Values{}
+	for _, key := range keys {
+		query.Add("key", hex.EncodeToString(key))
+	}
+	httpResp, err := t.send(nil, "GET", t.url(host, t.ownershipPath)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("send ownership request failed: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("send ownership request failed: %s", httpResp.Status)
+	}
+	ownershipResp := &OwnershipResponse{}
+	if _, err = ownershipResp.Decode(httpResp.Body); err != nil {
+		return nil, fmt.Errorf("send ownership request failed: %s", err)
+	}
+	return ownershipResp, nil
+}
+
+// GetSnapshot fetches the routing state snapshot of the server on given host through its debug endpoint
+func (t *Transporter) GetSnapshot(host string) (*NodeSnapshot, error) {
+	url := t.url(host, t.getSnapshotPath)
+	httpResp, err := t.send(nil, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("send getSnapshot request failed: %s", err)
+	}
+	defer httpResp.Body.Close()
+
+	data, err := ioutil.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("send getSnapshot request failed: %s", err)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("send getSnapshot request failed: %s", httpResp.Status)
+	}
+	return decodeSnapshot(data)
+}
+
 //	-------------------------------------------------------------------------
 //
 //	handler functions
@@ -138,6 +535,7 @@ func (t *Transporter) findSuccessorHandler(server *Server) http.HandlerFunc {
 			http.Error(w, "", http.StatusBadRequest)
 			return
 		}
+		req.Fresh = r.URL.Query().Get("fresh") == "true"
 
 		resp, err := server.FindSuccessor(req)
 		if resp == nil || err != nil {
@@ -153,6 +551,28 @@ func (t *Transporter) findSuccessorHandler(server *Server) http.HandlerFunc {
 	}
 }
 
+// batchFindSuccessorHandler handles incoming request to find the successors of many keys
+func (t *Transporter) batchFindSuccessorHandler(server *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &BatchFindSuccessorRequest{}
+		if _, err := req.Decode(r.Body); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+
+		resp, err := server.processBatchFindSuccessorRequest(req)
+		if resp == nil || err != nil {
+			http.Error(w, "Failed to return successors information", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := resp.Encode(w); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+	}
+}
+
 // notifyHandler handles incoming notify about possibe new predecessor
 func (t *Transporter) notifyHandler(server *Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -164,6 +584,10 @@ func (t *Transporter) notifyHandler(server *Server) http.HandlerFunc {
 
 		//resp, err := server.processNotifyRequest(req)
 		resp, err := server.notify(req)
+		if err == ErrOverloaded {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		if resp == nil || err != nil {
 			http.Error(w, "failed to notify", http.StatusBadRequest)
 			return
@@ -211,52 +635,209 @@ func (t *Transporter) getSuccessorHandler(server *Server) http.HandlerFunc {
 	}
 }
 
-// joinHandler handles the post request for this server to join an existing Chord ring
-// the url pattern is '/join?host='
-func (t *Transporter) joinHandler(server *Server) http.HandlerFunc {
+// getFingerTableHandler handles incoming request to log entries in finger table
+func (t *Transporter) getFingerTableHandler(server *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fingers := server.node.Finger()
+		for i := 0; i < server.config.HashBits; i++ {
+			log.Printf("host %s's finger at index %d: %s", server.config.Host, i, fingers[i])
+		}
+	}
+}
+
+// getSnapshotHandler handles incoming request to return a json snapshot of this node's routing state
+func (t *Transporter) getSnapshotHandler(server *Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		host := r.URL.Query().Get("host")
-		log.Println(host)
-		err := server.Join(host)
+		data, err := encodeSnapshot(server.Snapshot())
 		if err != nil {
-			http.Error(w, fmt.Sprintf("failed to join %s.%s", host, err), http.StatusBadRequest)
+			http.Error(w, "failed to return snapshot", http.StatusInternalServerError)
 			return
 		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(data)
+	}
+}
+
+// ownershipHandler handles incoming request to return the range of keys this node owns,
+// the url pattern is '/ownership?key=' with any number of hex encoded keys to check
+func (t *Transporter) ownershipHandler(server *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		keys := [][]byte{}
+		for _, k := range r.URL.Query()["key"] {
+			key, err := hex.DecodeString(k)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("bad key %s", k), http.StatusBadRequest)
+				return
+			}
+			keys = append(keys, key)
+		}
 
-		fmt.Fprintf(w, "success to join %s", host)
+		w.Header().Set("Content-Type", "application/json")
+		if _, err := server.ownership(keys).Encode(w); err != nil {
+			http.Error(w, "failed to return ownership", http.StatusInternalServerError)
+			return
+		}
 	}
 }
 
-// startHandler handles the incoming request to start this Chord server
-func (t *Transporter) startHandler(server *Server) http.HandlerFunc {
+// pingHandler handles incoming ping, answering with the identity of this node
+func (t *Transporter) pingHandler(server *Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := server.Start()
+		challenge, err := hex.DecodeString(r.URL.Query().Get("challenge"))
 		if err != nil {
-			fmt.Fprintf(w, "error to start server %s", server.config.Host)
-		} else {
-			fmt.Fprintf(w, "success to start server %s", server.config.Host)
+			http.Error(w, "invalid challenge", http.StatusBadRequest)
+			return
+		}
+		if _, err := server.processPingRequest(challenge).Encode(w); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
 		}
 	}
 }
 
-// stopHandler handles incoming request to stop the Chord server
-func (t *Transporter) stopHandler(server *Server) http.HandlerFunc {
+// leaveHandler handles incoming leave request of a neighbour leaving the ring
+func (t *Transporter) leaveHandler(server *Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		err := server.Stop()
+		req := &LeaveRequest{}
+		if _, err := req.Decode(r.Body); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+
+		if err := server.leave(req); err != nil {
+			http.Error(w, "failed to leave", http.StatusBadRequest)
+			return
+		}
+	}
+}
+
+// doHandler handles incoming command, running it if this node owns its key or forwarding it to the owner
+func (t *Transporter) doHandler(server *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &DoRequest{}
+		if _, err := req.Decode(r.Body); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+		// the command is queued under the hop that sent it, not under a source the request claims
+		req.source = remoteAddr(r)
+
+		doResp, err := server.processDoRequest(req)
+		if err == ErrOverloaded {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
 		if err != nil {
-			fmt.Fprintf(w, "error to stop server %s", server.config.Host)
-		} else {
-			fmt.Fprintf(w, "success to stop server %s", server.config.Host)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if _, err := doResp.Encode(w); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
 		}
 	}
 }
 
-// getFingerTableHandler handles incoming request to log entries in finger table
-func (t *Transporter) getFingerTableHandler(server *Server) http.HandlerFunc {
+// routeHandler handles incoming application message, delivering it if this node owns its key or passing it on
+func (t *Transporter) routeHandler(server *Server) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		fingers := server.node.Finger()
-		for i := 0; i < server.config.HashBits; i++ {
-			log.Printf("host %s's finger at index %d: %s", server.config.Host, i, fingers[i])
+		req := &RouteRequest{}
+		if _, err := req.Decode(r.Body); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+
+		routeResp, err := server.processRouteRequest(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		if _, err := routeResp.Encode(w); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+	}
+}
+
+// broadcastHandler handles incoming broadcast, answering once the nodes this node covers acknowledged it
+func (t *Transporter) broadcastHandler(server *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &BroadcastRequest{}
+		if _, err := req.Decode(r.Body); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+
+		if _, err := server.processBroadcastRequest(req).Encode(w); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
 		}
 	}
 }
+
+// sizeHandler handles incoming size gossip, the url pattern is '/size?share=' with the share of the sender
+func (t *Transporter) sizeHandler(server *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		share, err := strconv.ParseFloat(r.URL.Query().Get("share"), 64)
+		if err != nil || share <= 0 || share > 1 {
+			http.Error(w, "invalid share", http.StatusBadRequest)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(server.processSizeRequest(share)); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+	}
+}
+
+// loadHandler handles incoming request to return the load of this node
+func (t *Transporter) loadHandler(server *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loadResp, err := server.load()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(loadResp); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+	}
+}
+
+// transferHandler handles incoming request to export, import or delete the data of a range of keys
+func (t *Transporter) transferHandler(server *Server) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req := &TransferRequest{}
+		if _, err := req.Decode(r.Body); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+
+		transferResp, err := server.processTransferRequest(req)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(transferResp); err != nil {
+			http.Error(w, "", http.StatusBadRequest)
+			return
+		}
+	}
+}
+
+// checkRing rejects messages meant for another ring than the one this Transporter is scoped to
+func (t *Transporter) checkRing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ringID := r.Header.Get(ringHeader); ringID != t.ringID {
+			http.Error(w, fmt.Sprintf("%s: %q", ErrWrongRing, ringID), http.StatusConflict)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -40,3 +41,73 @@ func TestFindSuccessorHandler(t *testing.T) {
 		t.Error("wrong FindSuccessorResponse")
 	}
 }
+
+func TestRingTransportersShareListener(t *testing.T) {
+	rings := map[string][]*testNode{}
+	ids := map[string]bool{}
+	https := []*httptest.Server{}
+	defer func() {
+		for _, nodes := range rings {
+			for _, node := range nodes {
+				node.server.Stop()
+			}
+		}
+		for _, ts := range https {
+			ts.Close()
+		}
+	}()
+
+	// every listener hosts one node of each ring
+	for len(https) < 3 {
+		router := mux.NewRouter()
+		ts := httptest.NewUnstartedServer(router)
+		host := "http://" + ts.Listener.Addr().String()
+		nodes := map[string]*testNode{}
+		for _, ringID := range []string{"tenant-a", "tenant-b"} {
+			config := DefaultConfig(host)
+			config.HashBits = 8
+			config.NumNodes = 256
+			transporter := NewRingTransporter(ringID)
+			server := NewServer(host, config, transporter)
+			transporter.Install(server, router)
+			nodes[ringID] = &testNode{server: server, http: ts}
+		}
+		if ids[string(nodes["tenant-a"].server.node.ID)] {
+			ts.Close()
+			continue
+		}
+		ids[string(nodes["tenant-a"].server.node.ID)] = true
+
+		ts.Start()
+		https = append(https, ts)
+		for ringID, node := range nodes {
+			if err := node.server.Start(); err != nil {
+				t.Fatal(err)
+			}
+			rings[ringID] = append(rings[ringID], node)
+		}
+	}
+
+	// ring a only joins its first two nodes, so a message crossing rings would show up as a third member
+	joinTestNodes(t, rings["tenant-a"][:2])
+	joinTestNodes(t, rings["tenant-b"])
+	waitFor(t, 10*time.Second, "rings to stabilize", func() bool {
+		return testRingStable(rings["tenant-a"][:2]) && testRingStable(rings["tenant-b"])
+	})
+	if !rings["tenant-a"][2].server.alone() {
+		t.Errorf("expected the node of ring a that did not join to stay alone")
+	}
+
+	// a message for another ring is rejected
+	host := rings["tenant-a"][0].server.config.Host
+	req, _ := http.NewRequest("GET", host+"/ring/tenant-a/getSuccessor", nil)
+	req.Header.Set(ringHeader, "tenant-b")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusConflict {
+		t.Errorf("expected status %d for a message of another ring, got %d", http.StatusConflict, resp.StatusCode)
+	}
+}
@@ -3,6 +3,7 @@ package chord
 import (
 	"bytes"
 	"math/big"
+	"time"
 )
 
 // Checks if a key is STRICTLY between two IDs exclusively
@@ -73,3 +74,11 @@ func powerOffset(id []byte, exp int, mod int) []byte {
 	// Add together
 	return idInt.Bytes()
 }
+
+// durationOrDefault returns d, or def if d is not set
+func durationOrDefault(d time.Duration, def time.Duration) time.Duration {
+	if d <= 0 {
+		return def
+	}
+	return d
+}